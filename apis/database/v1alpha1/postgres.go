@@ -2,16 +2,91 @@ package v1alpha1
 
 import (
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // PostgresParameters define the desired state of an AWS IAM Role.
 type PostgresParameters struct {
 
-	// DatabaseSize is the size of the database in a valid Go notation
-	// e.g., 1Gi
+	// Namespace is the namespace in the target cluster the objects of the
+	// instance are created in. Defaults to default.
 	// +immutable
-	DatabaseSize string `json:"databaseSize"`
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+
+	// CreateNamespace makes the provider create the namespace if it does not
+	// exist and keep the NamespaceLabels on it. The namespace is not deleted
+	// with the instance.
+	// +optional
+	CreateNamespace *bool `json:"createNamespace,omitempty"`
+
+	// NamespaceLabels are the labels set on the namespace if CreateNamespace
+	// is true.
+	// +optional
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
+
+	// DatabaseSize is the size of the database in a valid Go notation
+	// e.g., 1Gi. The size can be increased if the StorageClass allows volume
+	// expansion, it cannot be decreased. It has to be set here or in the
+	// class of the instance.
+	// +optional
+	DatabaseSize string `json:"databaseSize,omitempty"`
+
+	// ClassName is the name of a PostgresClass whose values are copied into
+	// the unset parameters of the instance. Later changes of the class do
+	// not affect the instance.
+	// +optional
+	ClassName *string `json:"className,omitempty"`
+
+	// Resources are the CPU and memory requests and limits of the Postgres
+	// container. Changing them restarts the instance. Defaults to the class
+	// of the instance, or to requests of 50m CPU and 512Mi memory and limits
+	// of 250m CPU and 2Gi memory.
+	// +optional
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector restricts the nodes the pods of the instance run on to
+	// those with the given labels. Changing the scheduling of the instance
+	// restarts it.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations let the pods of the instance run on nodes with matching
+	// taints, e.g. nodes dedicated to databases.
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity are the node affinity and the pod affinity and anti-affinity
+	// of the pods of the instance.
+	// +optional
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+
+	// SpreadAcrossZones prefers to schedule the pods of the instance into
+	// different zones, i.e. onto nodes with different
+	// topology.kubernetes.io/zone labels. It adds to the pod anti-affinity
+	// given in Affinity.
+	// +optional
+	SpreadAcrossZones *bool `json:"spreadAcrossZones,omitempty"`
+
+	// TopologySpreadConstraints control how the pods of the instance are
+	// spread across the topology domains of the cluster. Constraints without
+	// a label selector select the pods of the instance.
+	// +optional
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName is the PriorityClass of the pods of the instance,
+	// e.g. to keep them from being preempted by less important workloads.
+	// +optional
+	PriorityClassName *string `json:"priorityClassName,omitempty"`
+
+	// Parameters are postgresql.conf settings of the instance, e.g.
+	// max_connections: "200". Parameters Postgres reads while running are
+	// reloaded, parameters it only reads at start restart the instance.
+	// Parameters of the class of the instance are used unless they are set
+	// here.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
 
 	// MasterUsername is the name for the master user.
 	// Constraints:
@@ -38,12 +113,318 @@ type PostgresParameters struct {
 	// +immutable
 	Port *int `json:"port,omitempty"`
 
+	// ServiceType is how the Services of the instance are exposed. Defaults
+	// to ClusterIP. The connection secret carries the address clients reach
+	// the instance at, i.e. the node port of a NodePort Service, the ingress
+	// of a LoadBalancer Service or the DNS name of a Headless Service.
+	// +optional
+	ServiceType *PostgresServiceType `json:"serviceType,omitempty"`
+
+	// ServiceAnnotations are added to the Services of the instance, e.g. to
+	// configure the load balancer of a cloud provider.
+	// +optional
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+
+	// LoadBalancerSourceRanges restricts the client IP ranges which may
+	// connect through a LoadBalancer Service, e.g. 10.0.0.0/8.
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// AdditionalConnectionDetails are added to the connection secret. Their
+	// values are Go templates rendered over the other connection details,
+	// e.g. "{{ .host }}:{{ .port }}" or "{{ index . \"ca.crt\" }}".
+	// +optional
+	AdditionalConnectionDetails map[string]string `json:"additionalConnectionDetails,omitempty"`
+
+	// Version is the Postgres version to run, e.g. 13.0. Changing the minor
+	// version rolls the instance to the new image, changing the major version
+	// upgrades the data directory using pg_upgrade. Downgrades are not
+	// supported.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)*$`
+	Version *string `json:"version,omitempty"`
+
+	// Image overrides the container image used for the instance. It must
+	// contain the Postgres version given in Version, by default the official
+	// postgres image for that version is used.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Replicas is the number of hot standby replicas streaming from the
+	// primary. If set, even to 0, the instance is run as a StatefulSet with a
+	// read-only Service in front of the replicas, otherwise as a single
+	// Deployment. Switching between the two is not supported.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int `json:"replicas,omitempty"`
+
 	// MasterPasswordSecretRef references the secret that contains the password used
 	// in the creation of this RDS instance. If no reference is given, a password
-	// will be auto-generated.
+	// will be auto-generated. Changing the reference or the content of the
+	// secret rotates the password of the master user.
 	// +optional
-	// +immutable
 	MasterPasswordSecretRef *runtimev1alpha1.SecretKeySelector `json:"masterPasswordSecretRef,omitempty"`
+
+	// RotationInterval is the interval after which an auto-generated master
+	// password is replaced with a new one, e.g. 720h. It is ignored if
+	// MasterPasswordSecretRef is set. By default the password is not rotated.
+	// +optional
+	RotationInterval *metav1.Duration `json:"rotationInterval,omitempty"`
+
+	// Source initializes the new instance with the data of a backup, a dump
+	// file or another instance. It is only used when the instance is created.
+	// +optional
+	// +immutable
+	Source *PostgresSource `json:"source,omitempty"`
+
+	// ReclaimPolicy decides what happens to the data of the instance when it
+	// is deleted. Delete, the default, deletes its PVCs. Retain keeps them
+	// detached, so that a new instance of the same name in the same
+	// namespace attaches them again. Snapshot takes a VolumeSnapshot of each
+	// PVC and deletes the PVCs once the snapshots are ready to use.
+	// +optional
+	ReclaimPolicy *PostgresReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshots
+	// taken with the Snapshot reclaim policy. Defaults to the default class
+	// of the CSI driver.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Extensions are installed into the database of the instance. Libraries
+	// known to be required by an extension, e.g. pg_stat_statements, are
+	// added to shared_preload_libraries, which restarts the instance.
+	// Removing an extension from the list drops it.
+	// +optional
+	Extensions []PostgresExtension `json:"extensions,omitempty"`
+
+	// TLS enables encrypted connections to the instance. By default
+	// connections are not encrypted.
+	// +optional
+	TLS *PostgresTLS `json:"tls,omitempty"`
+
+	// Monitoring runs a postgres_exporter sidecar in the pod of the instance
+	// and exposes its Prometheus metrics on a port of the Service. The
+	// exporter connects as a role with the privileges of pg_monitor, which
+	// the provider creates. Replicated instances are not supported yet.
+	// +optional
+	Monitoring *PostgresMonitoring `json:"monitoring,omitempty"`
+}
+
+// PostgresMonitoring configures the Prometheus metrics exporter of an
+// instance.
+type PostgresMonitoring struct {
+	// Image of the postgres_exporter. Defaults to
+	// quay.io/prometheuscommunity/postgres-exporter:v0.8.0.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Port the metrics are served on by the exporter and the Service.
+	// Defaults to 9187.
+	// +optional
+	Port *int `json:"port,omitempty"`
+
+	// Resources of the exporter container.
+	// +optional
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// ServiceMonitor creates a ServiceMonitor scraping the metrics port if
+	// the CRDs of the Prometheus Operator are installed in the cluster the
+	// instance runs in. It is skipped otherwise.
+	// +optional
+	ServiceMonitor *bool `json:"serviceMonitor,omitempty"`
+
+	// ServiceMonitorLabels are added to the ServiceMonitor, e.g. to match
+	// the serviceMonitorSelector of a Prometheus.
+	// +optional
+	ServiceMonitorLabels map[string]string `json:"serviceMonitorLabels,omitempty"`
+
+	// Interval the ServiceMonitor scrapes the metrics at, e.g. 30s. Defaults
+	// to the scrape interval of the Prometheus.
+	// +optional
+	Interval *string `json:"interval,omitempty"`
+}
+
+// PostgresTLS configures the certificate the instance serves TLS connections
+// with.
+type PostgresTLS struct {
+	// SecretName is the name of a kubernetes.io/tls Secret in the namespace
+	// of the instance holding the certificate in tls.crt, its key in tls.key
+	// and optionally the CA in ca.crt. If it is not set, the provider
+	// generates a CA and a server certificate for the Services of the
+	// instance and renews the certificate before it expires.
+	// +optional
+	SecretName *string `json:"secretName,omitempty"`
+
+	// Enforce rejects connections which are not encrypted.
+	// +optional
+	Enforce *bool `json:"enforce,omitempty"`
+
+	// RenewBefore is how long before its expiry a generated certificate is
+	// renewed, e.g. 360h. Defaults to 720h.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// PostgresServiceType is how the Services of an instance are exposed.
+// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer;Headless
+type PostgresServiceType string
+
+// Service types of an instance.
+const (
+	ServiceTypeClusterIP    PostgresServiceType = "ClusterIP"
+	ServiceTypeNodePort     PostgresServiceType = "NodePort"
+	ServiceTypeLoadBalancer PostgresServiceType = "LoadBalancer"
+	ServiceTypeHeadless     PostgresServiceType = "Headless"
+)
+
+// PostgresReclaimPolicy is what happens to the data of an instance when it is
+// deleted.
+// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+type PostgresReclaimPolicy string
+
+// Reclaim policies of an instance.
+const (
+	ReclaimPolicyDelete   PostgresReclaimPolicy = "Delete"
+	ReclaimPolicyRetain   PostgresReclaimPolicy = "Retain"
+	ReclaimPolicySnapshot PostgresReclaimPolicy = "Snapshot"
+)
+
+// PostgresExtension is an extension installed into a database.
+type PostgresExtension struct {
+	// Name of the extension, e.g. pgcrypto.
+	Name string `json:"name"`
+
+	// Version of the extension. Defaults to the default version of the
+	// extension when it is created, changing it updates the extension.
+	// +optional
+	Version *string `json:"version,omitempty"`
+
+	// Schema the objects of the extension are created in. Defaults to the
+	// current schema, i.e. public.
+	// +optional
+	// +immutable
+	Schema *string `json:"schema,omitempty"`
+}
+
+// PostgresExtensionStatus reports an extension installed by the provider.
+type PostgresExtensionStatus struct {
+	// Name of the extension.
+	Name string `json:"name"`
+
+	// Version of the extension installed.
+	Version string `json:"version"`
+}
+
+// PostgresSource is the data a new instance is initialized with. Exactly one
+// of its fields must be set.
+type PostgresSource struct {
+	// Backup restores a backup taken by a PostgresBackup.
+	// +optional
+	Backup *PostgresBackupSource `json:"backup,omitempty"`
+
+	// PVC restores a dump file from a PersistentVolumeClaim.
+	// +optional
+	PVC *PostgresPVCSource `json:"pvc,omitempty"`
+
+	// Postgres clones all databases and roles of another Postgres instance.
+	// +optional
+	Postgres *PostgresCloneSource `json:"postgres,omitempty"`
+
+	// Snapshot provisions the PVC of the instance from a PostgresSnapshot.
+	// It is only supported for instances without replicas.
+	// +optional
+	Snapshot *PostgresSnapshotSource `json:"snapshot,omitempty"`
+}
+
+// PostgresBackupSource restores a backup taken by a PostgresBackup.
+type PostgresBackupSource struct {
+	// Name of the PostgresBackup.
+	Name string `json:"name"`
+
+	// Location of the backup in the destination of the PostgresBackup, i.e.
+	// the path inside the PVC or the S3 URL. Defaults to the last successful
+	// backup.
+	// +optional
+	Location *string `json:"location,omitempty"`
+}
+
+// PostgresPVCSource restores a dump file from a PersistentVolumeClaim. Files
+// ending in .dump are restored with pg_restore into the database of the
+// instance, other files are run with psql and may be gzipped.
+type PostgresPVCSource struct {
+	// ClaimName is the name of the PersistentVolumeClaim in the namespace of
+	// the new instance.
+	ClaimName string `json:"claimName"`
+
+	// Path of the dump file inside the claim.
+	Path string `json:"path"`
+}
+
+// PostgresCloneSource clones another Postgres instance.
+type PostgresCloneSource struct {
+	// Name of the Postgres instance which is cloned.
+	Name string `json:"name"`
+}
+
+// PostgresSnapshotSource provisions the PVC of a new instance from a CSI
+// snapshot. The snapshot has to be in the namespace of the new instance and
+// its restore size must not exceed the DatabaseSize of the instance.
+type PostgresSnapshotSource struct {
+	// Name of the PostgresSnapshot.
+	Name string `json:"name"`
+}
+
+// Phases of a major version upgrade.
+const (
+	UpgradePhaseScalingDown = "ScalingDown"
+	UpgradePhaseUpgrading   = "Upgrading"
+	UpgradePhaseSucceeded   = "Succeeded"
+	UpgradePhaseFailed      = "Failed"
+)
+
+// PostgresUpgradeStatus reports the progress of a major version upgrade.
+type PostgresUpgradeStatus struct {
+	// FromVersion is the version the instance is upgraded from.
+	FromVersion string `json:"fromVersion"`
+
+	// ToVersion is the version the instance is upgraded to.
+	ToVersion string `json:"toVersion"`
+
+	// Phase is the current phase of the upgrade, one of ScalingDown,
+	// Upgrading, Succeeded or Failed.
+	Phase string `json:"phase"`
+}
+
+// Phases of the restore of a new instance from its source.
+const (
+	RestorePhaseRestoring = "Restoring"
+	RestorePhaseSucceeded = "Succeeded"
+	RestorePhaseFailed    = "Failed"
+)
+
+// PostgresRestoreStatus reports the progress of initializing a new instance
+// from its source.
+type PostgresRestoreStatus struct {
+	// Source is the file, URL or instance the data is restored from.
+	Source string `json:"source"`
+
+	// Phase is the current phase of the restore, one of Restoring,
+	// Succeeded or Failed.
+	Phase string `json:"phase"`
+}
+
+// PostgresFailoverStatus records an automatic failover of a replicated
+// instance.
+type PostgresFailoverStatus struct {
+	// FromPod is the pod which ran the failed primary.
+	FromPod string `json:"fromPod"`
+
+	// ToPod is the standby which was promoted to primary.
+	ToPod string `json:"toPod"`
+
+	// Time is the time of the failover.
+	Time metav1.Time `json:"time"`
 }
 
 // An PostgresSpec defines the desired state of an Postgres.
@@ -54,8 +435,138 @@ type PostgresSpec struct {
 
 // PostgresExternalStatus keeps the state for the external resource
 type PostgresExternalStatus struct {
-	// The status of the PVC for this Postgres database
+	// The status of the PVC for this Postgres database, i.e. its phase.
 	PVCStatus string `json:"pvcStatus"`
+
+	// Capacity is the storage capacity of the volume bound to the PVC.
+	// +optional
+	Capacity string `json:"capacity,omitempty"`
+
+	// StorageClass is the StorageClass of the PVC.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// PodPhase is the phase of the pod running the instance, or the primary
+	// of a replicated instance.
+	// +optional
+	PodPhase string `json:"podPhase,omitempty"`
+
+	// Restarts is the number of container restarts of that pod.
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+
+	// NodeName is the node that pod runs on.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Image is the image the Postgres container of that pod runs.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Rollout reports the rollout of the Deployment or StatefulSet of the
+	// instance, i.e. Complete, Progressing or Failed.
+	// +optional
+	Rollout string `json:"rollout,omitempty"`
+
+	// ServerVersion is the version reported by the running server.
+	// +optional
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// StorageResizeStatus reports the progress of an ongoing expansion of the
+	// PVC, i.e. Resizing or FileSystemResizePending.
+	// +optional
+	StorageResizeStatus string `json:"storageResizeStatus,omitempty"`
+
+	// Upgrade reports the progress of the last major version upgrade.
+	// +optional
+	Upgrade *PostgresUpgradeStatus `json:"upgrade,omitempty"`
+
+	// Restore reports the progress of initializing the instance from its
+	// source.
+	// +optional
+	Restore *PostgresRestoreStatus `json:"restore,omitempty"`
+
+	// ConfigChecksum is the checksum of the postgresql.conf the running
+	// instance has loaded.
+	// +optional
+	ConfigChecksum string `json:"configChecksum,omitempty"`
+
+	// PendingRestart lists the parameters whose new value takes effect once
+	// the instance has been restarted.
+	// +optional
+	PendingRestart []string `json:"pendingRestart,omitempty"`
+
+	// Extensions are the extensions installed in the database of the
+	// instance and their versions.
+	// +optional
+	Extensions []PostgresExtensionStatus `json:"extensions,omitempty"`
+
+	// CertificateNotAfter is the expiry of the certificate the instance
+	// serves TLS connections with.
+	// +optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+
+	// LastPasswordRotation is the time the master password was last set.
+	// +optional
+	LastPasswordRotation *metav1.Time `json:"lastPasswordRotation,omitempty"`
+
+	// Primary is the pod currently running the primary of a replicated
+	// instance.
+	// +optional
+	Primary string `json:"primary,omitempty"`
+
+	// LastFailover records the last automatic failover of a replicated
+	// instance.
+	// +optional
+	LastFailover *PostgresFailoverStatus `json:"lastFailover,omitempty"`
+
+	// FinalSnapshots are the VolumeSnapshots taken of the PVCs of the
+	// instance when it was deleted with the Snapshot reclaim policy.
+	// +optional
+	FinalSnapshots []string `json:"finalSnapshots,omitempty"`
+
+	// PodSecurityProfile is the security profile of the provider config the
+	// pods of the instance are rendered with, Default or Restricted.
+	// +optional
+	PodSecurityProfile string `json:"podSecurityProfile,omitempty"`
+
+	// MonitoringRole is the role the metrics exporter of the instance
+	// connects as, once it has been created.
+	// +optional
+	MonitoringRole string `json:"monitoringRole,omitempty"`
+}
+
+// TypeStorageResize reports whether a change of the databaseSize could be
+// applied to the PVCs of an instance.
+const TypeStorageResize runtimev1alpha1.ConditionType = "StorageResize"
+
+// Reasons of the StorageResize condition.
+const (
+	ReasonResizeRejected runtimev1alpha1.ConditionReason = "ResizeRejected"
+	ReasonResizeApplied  runtimev1alpha1.ConditionReason = "ResizeApplied"
+)
+
+// StorageResizeRejected returns a condition that indicates the requested
+// databaseSize cannot be applied to the PVCs, which keep their size.
+func StorageResizeRejected(err error) runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeStorageResize,
+		Status:             v1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonResizeRejected,
+		Message:            err.Error(),
+	}
+}
+
+// StorageResizeApplied returns a condition that indicates the requested
+// databaseSize is applied to the PVCs.
+func StorageResizeApplied() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeStorageResize,
+		Status:             v1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonResizeApplied,
+	}
 }
 
 // An PostgresStatus represents the observed state of an Postgres.
@@ -69,6 +580,16 @@ type PostgresStatus struct {
 // An Postgres is a managed resource that represents a Postgres database.
 // +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="CLASS",type="string",JSONPath=".spec.forProvider.className"
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.atProvider.serverVersion"
+// +kubebuilder:printcolumn:name="POD",type="string",JSONPath=".status.atProvider.podPhase"
+// +kubebuilder:printcolumn:name="RESTARTS",type="integer",JSONPath=".status.atProvider.restarts"
+// +kubebuilder:printcolumn:name="ROLLOUT",type="string",JSONPath=".status.atProvider.rollout"
+// +kubebuilder:printcolumn:name="PVC",type="string",JSONPath=".status.atProvider.pvcStatus"
+// +kubebuilder:printcolumn:name="CAPACITY",type="string",JSONPath=".status.atProvider.capacity"
+// +kubebuilder:printcolumn:name="STORAGECLASS",type="string",JSONPath=".status.atProvider.storageClass",priority=1
+// +kubebuilder:printcolumn:name="NODE",type="string",JSONPath=".status.atProvider.nodeName",priority=1
+// +kubebuilder:printcolumn:name="IMAGE",type="string",JSONPath=".status.atProvider.image",priority=1
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}
@@ -0,0 +1,162 @@
+package v1alpha1
+
+import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgresBackupPVCDestination writes backups to a PersistentVolumeClaim.
+type PostgresBackupPVCDestination struct {
+	// ClaimName is the name of an existing PersistentVolumeClaim in the
+	// namespace of the Postgres instance.
+	ClaimName string `json:"claimName"`
+
+	// Path is the directory inside the claim the backups are written to.
+	// Defaults to the name of the PostgresBackup.
+	// +optional
+	Path *string `json:"path,omitempty"`
+}
+
+// PostgresBackupS3Destination uploads backups to an S3 compatible bucket.
+type PostgresBackupS3Destination struct {
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Prefix is the key prefix the backups are uploaded under. Defaults to
+	// the name of the PostgresBackup.
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+
+	// Endpoint is the URL of an S3 compatible service, e.g. a MinIO
+	// instance. Defaults to AWS S3.
+	// +optional
+	Endpoint *string `json:"endpoint,omitempty"`
+
+	// Region of the bucket.
+	// +optional
+	Region *string `json:"region,omitempty"`
+
+	// CredentialsSecretName is the name of a Secret in the namespace of the
+	// Postgres instance holding the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY used for the upload.
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// PostgresBackupDestination is where backups are written to. Exactly one of
+// its fields must be set.
+type PostgresBackupDestination struct {
+	// PVC writes the backups to a PersistentVolumeClaim.
+	// +optional
+	PVC *PostgresBackupPVCDestination `json:"pvc,omitempty"`
+
+	// S3 uploads the backups to an S3 compatible bucket.
+	// +optional
+	S3 *PostgresBackupS3Destination `json:"s3,omitempty"`
+}
+
+// PostgresBackupParameters define the desired state of scheduled logical
+// backups of a Postgres instance.
+type PostgresBackupParameters struct {
+	// PostgresName is the name of the Postgres instance which is backed up.
+	// +optional
+	// +immutable
+	PostgresName *string `json:"postgresName,omitempty"`
+
+	// PostgresNameRef references the Postgres instance to set PostgresName.
+	// +optional
+	PostgresNameRef *runtimev1alpha1.Reference `json:"postgresNameRef,omitempty"`
+
+	// PostgresNameSelector selects a reference to a Postgres instance to set
+	// PostgresName.
+	// +optional
+	PostgresNameSelector *runtimev1alpha1.Selector `json:"postgresNameSelector,omitempty"`
+
+	// Schedule is the cron schedule the backups are taken on, e.g.
+	// "0 3 * * *".
+	Schedule string `json:"schedule"`
+
+	// Suspend stops taking new backups while set to true.
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// Database is the database dumped with pg_dump. If not set all
+	// databases, roles and tablespaces are dumped with pg_dumpall.
+	// +optional
+	Database *string `json:"database,omitempty"`
+
+	// Retention is the number of backups kept in the destination, older
+	// backups are deleted after each successful backup. Defaults to 7.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Retention *int `json:"retention,omitempty"`
+
+	// Destination is where the backups are written to.
+	Destination PostgresBackupDestination `json:"destination"`
+}
+
+// A PostgresBackupSpec defines the desired state of a PostgresBackup.
+type PostgresBackupSpec struct {
+	runtimev1alpha1.ResourceSpec `json:",inline"`
+	ForProvider                  PostgresBackupParameters `json:"forProvider"`
+}
+
+// PostgresBackupObservation reports the backups taken.
+type PostgresBackupObservation struct {
+	// LastScheduleTime is the last time a backup was started.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// LastSuccessfulBackupTime is the time the last successful backup
+	// finished.
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+
+	// LastBackupLocation is the file or the S3 URL of the last successful
+	// backup.
+	// +optional
+	LastBackupLocation string `json:"lastBackupLocation,omitempty"`
+
+	// LastBackupSizeBytes is the size of the last successful backup.
+	// +optional
+	LastBackupSizeBytes int64 `json:"lastBackupSizeBytes,omitempty"`
+
+	// PodSecurityProfile is the security profile of the provider config the
+	// backup pods are rendered with, Default or Restricted.
+	// +optional
+	PodSecurityProfile string `json:"podSecurityProfile,omitempty"`
+}
+
+// A PostgresBackupStatus represents the observed state of a PostgresBackup.
+type PostgresBackupStatus struct {
+	runtimev1alpha1.ResourceStatus `json:",inline"`
+	AtProvider                     PostgresBackupObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A PostgresBackup is a managed resource that represents scheduled logical
+// backups of a Postgres instance.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="POSTGRES",type="string",JSONPath=".spec.forProvider.postgresName"
+// +kubebuilder:printcolumn:name="SCHEDULE",type="string",JSONPath=".spec.forProvider.schedule"
+// +kubebuilder:printcolumn:name="LAST-BACKUP",type="date",JSONPath=".status.atProvider.lastSuccessfulBackupTime"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}
+type PostgresBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresBackupSpec   `json:"spec"`
+	Status PostgresBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PostgresBackupList contains a list of PostgresBackups
+type PostgresBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresBackup `json:"items"`
+}
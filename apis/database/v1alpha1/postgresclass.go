@@ -0,0 +1,53 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgresClassSpec holds the defaults of the Postgres instances referencing
+// the class. Values set on an instance take precedence.
+type PostgresClassSpec struct {
+	// DatabaseSize is the size of the database in a valid Go notation
+	// e.g., 10Gi.
+	// +optional
+	DatabaseSize *string `json:"databaseSize,omitempty"`
+
+	// StorageClass specifies the storage class used for the PVC.
+	// +optional
+	StorageClass *string `json:"storageClass,omitempty"`
+
+	// Resources are the CPU and memory requests and limits of the Postgres
+	// container.
+	// +optional
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Parameters are postgresql.conf settings of the instances.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A PostgresClass is a named preset of the size and configuration of Postgres
+// instances, e.g. small, medium and large.
+// +kubebuilder:printcolumn:name="DATABASE-SIZE",type="string",JSONPath=".spec.databaseSize"
+// +kubebuilder:printcolumn:name="CPU",type="string",JSONPath=".spec.resources.requests.cpu"
+// +kubebuilder:printcolumn:name="MEMORY",type="string",JSONPath=".spec.resources.requests.memory"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,in-cluster}
+type PostgresClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PostgresClassSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// PostgresClassList contains a list of PostgresClasses
+type PostgresClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresClass `json:"items"`
+}
@@ -0,0 +1,122 @@
+package v1alpha1
+
+import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgresDatabaseParameters define the desired state of a database inside a
+// Postgres instance. The name of the database is the external name of the
+// resource.
+type PostgresDatabaseParameters struct {
+	// PostgresName is the name of the Postgres instance the database is
+	// created in.
+	// +optional
+	// +immutable
+	PostgresName *string `json:"postgresName,omitempty"`
+
+	// PostgresNameRef references the Postgres instance to set PostgresName.
+	// +optional
+	PostgresNameRef *runtimev1alpha1.Reference `json:"postgresNameRef,omitempty"`
+
+	// PostgresNameSelector selects a reference to a Postgres instance to set
+	// PostgresName.
+	// +optional
+	PostgresNameSelector *runtimev1alpha1.Selector `json:"postgresNameSelector,omitempty"`
+
+	// Owner is the role owning the database, by default the master user.
+	// +optional
+	Owner *string `json:"owner,omitempty"`
+
+	// OwnerRef references the PostgresRole to set Owner.
+	// +optional
+	OwnerRef *runtimev1alpha1.Reference `json:"ownerRef,omitempty"`
+
+	// OwnerSelector selects a reference to a PostgresRole to set Owner.
+	// +optional
+	OwnerSelector *runtimev1alpha1.Selector `json:"ownerSelector,omitempty"`
+
+	// Template is the database the new database is copied from.
+	// +optional
+	// +immutable
+	Template *string `json:"template,omitempty"`
+
+	// Encoding is the character set encoding of the database, e.g. UTF8.
+	// +optional
+	// +immutable
+	Encoding *string `json:"encoding,omitempty"`
+
+	// LCCollate is the collation order of the database, e.g. en_US.utf8.
+	// +optional
+	// +immutable
+	LCCollate *string `json:"lcCollate,omitempty"`
+
+	// LCCType is the character classification of the database.
+	// +optional
+	// +immutable
+	LCCType *string `json:"lcCType,omitempty"`
+
+	// ConnectionLimit is the number of concurrent connections to the
+	// database, -1 means no limit.
+	// +optional
+	ConnectionLimit *int `json:"connectionLimit,omitempty"`
+
+	// AllowConnections allows connections to the database. Defaults to true.
+	// +optional
+	AllowConnections *bool `json:"allowConnections,omitempty"`
+
+	// Extensions are installed into the database. Extensions which need
+	// shared_preload_libraries have to be added to the parameters or the
+	// extensions of the Postgres instance. Removing an extension from the
+	// list drops it.
+	// +optional
+	Extensions []PostgresExtension `json:"extensions,omitempty"`
+}
+
+// A PostgresDatabaseSpec defines the desired state of a PostgresDatabase.
+type PostgresDatabaseSpec struct {
+	runtimev1alpha1.ResourceSpec `json:",inline"`
+	ForProvider                  PostgresDatabaseParameters `json:"forProvider"`
+}
+
+// PostgresDatabaseObservation is the observed state of a database.
+type PostgresDatabaseObservation struct {
+	// Extensions are the extensions installed in the database and their
+	// versions.
+	// +optional
+	Extensions []PostgresExtensionStatus `json:"extensions,omitempty"`
+}
+
+// A PostgresDatabaseStatus represents the observed state of a
+// PostgresDatabase.
+type PostgresDatabaseStatus struct {
+	runtimev1alpha1.ResourceStatus `json:",inline"`
+	AtProvider                     PostgresDatabaseObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A PostgresDatabase is a managed resource that represents a database inside
+// a Postgres instance.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="POSTGRES",type="string",JSONPath=".spec.forProvider.postgresName"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}
+type PostgresDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresDatabaseSpec   `json:"spec"`
+	Status PostgresDatabaseStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PostgresDatabaseList contains a list of PostgresDatabases
+type PostgresDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresDatabase `json:"items"`
+}
@@ -0,0 +1,154 @@
+package v1alpha1
+
+import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgresPoolerParameters define the desired state of a PgBouncer
+// connection pooler in front of a Postgres instance.
+type PostgresPoolerParameters struct {
+	// PostgresName is the name of the Postgres instance the pooler connects
+	// to. The pooler runs in the namespace of the instance and connects to
+	// the primary of a replicated instance.
+	// +optional
+	// +immutable
+	PostgresName *string `json:"postgresName,omitempty"`
+
+	// PostgresNameRef references the Postgres instance to set PostgresName.
+	// +optional
+	PostgresNameRef *runtimev1alpha1.Reference `json:"postgresNameRef,omitempty"`
+
+	// PostgresNameSelector selects a reference to a Postgres instance to set
+	// PostgresName.
+	// +optional
+	PostgresNameSelector *runtimev1alpha1.Selector `json:"postgresNameSelector,omitempty"`
+
+	// PoolMode is when a server connection is released back to the pool:
+	// after the client disconnects, after each transaction or after each
+	// statement. Defaults to transaction.
+	// +optional
+	PoolMode *PostgresPoolMode `json:"poolMode,omitempty"`
+
+	// DefaultPoolSize is the number of server connections per user and
+	// database. Defaults to 20.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DefaultPoolSize *int `json:"defaultPoolSize,omitempty"`
+
+	// MinPoolSize is the number of server connections kept open per user and
+	// database even if they are idle. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinPoolSize *int `json:"minPoolSize,omitempty"`
+
+	// ReservePoolSize is the number of additional server connections a pool
+	// may open while clients wait for a connection. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ReservePoolSize *int `json:"reservePoolSize,omitempty"`
+
+	// MaxClientConnections is the number of client connections each replica
+	// of the pooler accepts. Defaults to 1000.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxClientConnections *int `json:"maxClientConnections,omitempty"`
+
+	// Replicas is the number of PgBouncer pods. Each of them opens its own
+	// pools to the instance. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int `json:"replicas,omitempty"`
+
+	// Port the Service of the pooler listens on. Defaults to 5432.
+	// +optional
+	Port *int `json:"port,omitempty"`
+
+	// Image of PgBouncer. Defaults to edoburu/pgbouncer:1.15.0.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Resources of the PgBouncer container.
+	// +optional
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// PostgresPoolMode is when PgBouncer releases a server connection.
+// +kubebuilder:validation:Enum=session;transaction;statement
+type PostgresPoolMode string
+
+// Pool modes of a pooler.
+const (
+	PoolModeSession     PostgresPoolMode = "session"
+	PoolModeTransaction PostgresPoolMode = "transaction"
+	PoolModeStatement   PostgresPoolMode = "statement"
+)
+
+// A PostgresPoolerSpec defines the desired state of a PostgresPooler.
+type PostgresPoolerSpec struct {
+	runtimev1alpha1.ResourceSpec `json:",inline"`
+	ForProvider                  PostgresPoolerParameters `json:"forProvider"`
+}
+
+// PostgresPoolerObservation reports the state of the PgBouncer Deployment.
+type PostgresPoolerObservation struct {
+	// Namespace is the namespace of the pooler, i.e. of the instance it
+	// connects to.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ReadyReplicas is the number of PgBouncer pods ready to accept
+	// connections.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// Users is the number of login roles of the instance in the userlist of
+	// the pooler.
+	// +optional
+	Users int `json:"users,omitempty"`
+
+	// ConfigChecksum is the checksum of the pgbouncer.ini and userlist the
+	// running pods of the pooler have loaded.
+	// +optional
+	ConfigChecksum string `json:"configChecksum,omitempty"`
+
+	// PodSecurityProfile is the security profile the pods of the pooler are
+	// rendered with, taken from the provider config.
+	// +optional
+	PodSecurityProfile string `json:"podSecurityProfile,omitempty"`
+}
+
+// A PostgresPoolerStatus represents the observed state of a PostgresPooler.
+type PostgresPoolerStatus struct {
+	runtimev1alpha1.ResourceStatus `json:",inline"`
+	AtProvider                     PostgresPoolerObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A PostgresPooler is a managed resource that represents a PgBouncer
+// connection pooler in front of a Postgres instance.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="POSTGRES",type="string",JSONPath=".spec.forProvider.postgresName"
+// +kubebuilder:printcolumn:name="POOL-MODE",type="string",JSONPath=".spec.forProvider.poolMode"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}
+type PostgresPooler struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresPoolerSpec   `json:"spec"`
+	Status PostgresPoolerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PostgresPoolerList contains a list of PostgresPoolers
+type PostgresPoolerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresPooler `json:"items"`
+}
@@ -0,0 +1,120 @@
+package v1alpha1
+
+import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgresGrant grants privileges on a database to a role.
+type PostgresGrant struct {
+	// Database is the name of the database the privileges are granted on.
+	Database string `json:"database"`
+
+	// Privileges are the privileges granted on the database. ALL grants
+	// CONNECT, CREATE and TEMPORARY.
+	Privileges []PostgresDatabasePrivilege `json:"privileges"`
+}
+
+// PostgresDatabasePrivilege is a privilege which can be granted on a database.
+// +kubebuilder:validation:Enum=CONNECT;CREATE;TEMPORARY;ALL
+type PostgresDatabasePrivilege string
+
+// Privileges which can be granted on a database.
+const (
+	PrivilegeConnect   PostgresDatabasePrivilege = "CONNECT"
+	PrivilegeCreate    PostgresDatabasePrivilege = "CREATE"
+	PrivilegeTemporary PostgresDatabasePrivilege = "TEMPORARY"
+	PrivilegeAll       PostgresDatabasePrivilege = "ALL"
+)
+
+// PostgresRoleParameters define the desired state of a role inside a Postgres
+// instance. The name of the role is the external name of the resource.
+type PostgresRoleParameters struct {
+	// PostgresName is the name of the Postgres instance the role is created in.
+	// +optional
+	// +immutable
+	PostgresName *string `json:"postgresName,omitempty"`
+
+	// PostgresNameRef references the Postgres instance to set PostgresName.
+	// +optional
+	PostgresNameRef *runtimev1alpha1.Reference `json:"postgresNameRef,omitempty"`
+
+	// PostgresNameSelector selects a reference to a Postgres instance to set
+	// PostgresName.
+	// +optional
+	PostgresNameSelector *runtimev1alpha1.Selector `json:"postgresNameSelector,omitempty"`
+
+	// Login allows the role to log in. Defaults to true.
+	// +optional
+	Login *bool `json:"login,omitempty"`
+
+	// CreateDB allows the role to create databases.
+	// +optional
+	CreateDB *bool `json:"createDb,omitempty"`
+
+	// CreateRole allows the role to create, alter and drop other roles.
+	// +optional
+	CreateRole *bool `json:"createRole,omitempty"`
+
+	// Inherit makes the role inherit the privileges of the roles it is a
+	// member of. Defaults to true.
+	// +optional
+	Inherit *bool `json:"inherit,omitempty"`
+
+	// ConnectionLimit is the number of concurrent connections the role can
+	// make, -1 means no limit.
+	// +optional
+	ConnectionLimit *int `json:"connectionLimit,omitempty"`
+
+	// MemberOf are the roles this role is a member of.
+	// +optional
+	MemberOf []string `json:"memberOf,omitempty"`
+
+	// Grants are the privileges granted to the role on databases.
+	// +optional
+	Grants []PostgresGrant `json:"grants,omitempty"`
+
+	// PasswordSecretRef references the secret that contains the password of
+	// the role. If no reference is given, a password will be auto-generated.
+	// Changes to the password in the secret are applied to the role.
+	// +optional
+	PasswordSecretRef *runtimev1alpha1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
+}
+
+// A PostgresRoleSpec defines the desired state of a PostgresRole.
+type PostgresRoleSpec struct {
+	runtimev1alpha1.ResourceSpec `json:",inline"`
+	ForProvider                  PostgresRoleParameters `json:"forProvider"`
+}
+
+// A PostgresRoleStatus represents the observed state of a PostgresRole.
+type PostgresRoleStatus struct {
+	runtimev1alpha1.ResourceStatus `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+
+// A PostgresRole is a managed resource that represents a role inside a
+// Postgres instance.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="POSTGRES",type="string",JSONPath=".spec.forProvider.postgresName"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}
+type PostgresRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresRoleSpec   `json:"spec"`
+	Status PostgresRoleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PostgresRoleList contains a list of PostgresRoles
+type PostgresRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresRole `json:"items"`
+}
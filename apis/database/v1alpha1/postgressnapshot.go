@@ -0,0 +1,99 @@
+package v1alpha1
+
+import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgresSnapshotParameters define the desired state of a CSI snapshot of
+// the data of a Postgres instance.
+type PostgresSnapshotParameters struct {
+	// PostgresName is the name of the Postgres instance whose data is
+	// snapshotted. The snapshot of a replicated instance is taken of the PVC
+	// of its primary.
+	// +optional
+	// +immutable
+	PostgresName *string `json:"postgresName,omitempty"`
+
+	// PostgresNameRef references the Postgres instance to set PostgresName.
+	// +optional
+	PostgresNameRef *runtimev1alpha1.Reference `json:"postgresNameRef,omitempty"`
+
+	// PostgresNameSelector selects a reference to a Postgres instance to set
+	// PostgresName.
+	// +optional
+	PostgresNameSelector *runtimev1alpha1.Selector `json:"postgresNameSelector,omitempty"`
+
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshot.
+	// Defaults to the default class of the CSI driver.
+	// +optional
+	// +immutable
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// A PostgresSnapshotSpec defines the desired state of a PostgresSnapshot.
+type PostgresSnapshotSpec struct {
+	runtimev1alpha1.ResourceSpec `json:",inline"`
+	ForProvider                  PostgresSnapshotParameters `json:"forProvider"`
+}
+
+// PostgresSnapshotObservation reports the VolumeSnapshot taken.
+type PostgresSnapshotObservation struct {
+	// Namespace is the namespace of the VolumeSnapshot, i.e. of the
+	// instance it was taken of.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SourcePVC is the PVC the snapshot was taken of.
+	// +optional
+	SourcePVC string `json:"sourcePVC,omitempty"`
+
+	// ReadyToUse is true once a new instance can be restored from the
+	// snapshot.
+	// +optional
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+
+	// RestoreSize is the minimum size of a PVC restored from the snapshot.
+	// +optional
+	RestoreSize string `json:"restoreSize,omitempty"`
+
+	// CreationTime is the time the snapshot was taken by the CSI driver.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+}
+
+// A PostgresSnapshotStatus represents the observed state of a
+// PostgresSnapshot.
+type PostgresSnapshotStatus struct {
+	runtimev1alpha1.ResourceStatus `json:",inline"`
+	AtProvider                     PostgresSnapshotObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A PostgresSnapshot is a managed resource that represents a CSI
+// VolumeSnapshot of the data of a Postgres instance.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="POSTGRES",type="string",JSONPath=".spec.forProvider.postgresName"
+// +kubebuilder:printcolumn:name="READY-TO-USE",type="boolean",JSONPath=".status.atProvider.readyToUse"
+// +kubebuilder:printcolumn:name="RESTORE-SIZE",type="string",JSONPath=".status.atProvider.restoreSize"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}
+type PostgresSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresSnapshotSpec   `json:"spec"`
+	Status PostgresSnapshotStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PostgresSnapshotList contains a list of PostgresSnapshots
+type PostgresSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresSnapshot `json:"items"`
+}
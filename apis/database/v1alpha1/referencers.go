@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reference"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PostgresName extracts the name of a resolved Postgres instance. The
+// provider names the objects of an instance after the resource, not after
+// its external name.
+func PostgresName() reference.ExtractValueFn {
+	return func(mg resource.Managed) string {
+		return mg.GetName()
+	}
+}
+
+// ResolveReferences of this PostgresDatabase
+func (mg *PostgresDatabase) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: reference.FromPtrValue(mg.Spec.ForProvider.PostgresName),
+		Reference:    mg.Spec.ForProvider.PostgresNameRef,
+		Selector:     mg.Spec.ForProvider.PostgresNameSelector,
+		To:           reference.To{Managed: &Postgres{}, List: &PostgresList{}},
+		Extract:      PostgresName(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.postgresName")
+	}
+	mg.Spec.ForProvider.PostgresName = reference.ToPtrValue(rsp.ResolvedValue)
+	mg.Spec.ForProvider.PostgresNameRef = rsp.ResolvedReference
+
+	rsp, err = r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: reference.FromPtrValue(mg.Spec.ForProvider.Owner),
+		Reference:    mg.Spec.ForProvider.OwnerRef,
+		Selector:     mg.Spec.ForProvider.OwnerSelector,
+		To:           reference.To{Managed: &PostgresRole{}, List: &PostgresRoleList{}},
+		Extract:      reference.ExternalName(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.owner")
+	}
+	mg.Spec.ForProvider.Owner = reference.ToPtrValue(rsp.ResolvedValue)
+	mg.Spec.ForProvider.OwnerRef = rsp.ResolvedReference
+
+	return nil
+}
+
+// ResolveReferences of this PostgresRole
+func (mg *PostgresRole) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: reference.FromPtrValue(mg.Spec.ForProvider.PostgresName),
+		Reference:    mg.Spec.ForProvider.PostgresNameRef,
+		Selector:     mg.Spec.ForProvider.PostgresNameSelector,
+		To:           reference.To{Managed: &Postgres{}, List: &PostgresList{}},
+		Extract:      PostgresName(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.postgresName")
+	}
+	mg.Spec.ForProvider.PostgresName = reference.ToPtrValue(rsp.ResolvedValue)
+	mg.Spec.ForProvider.PostgresNameRef = rsp.ResolvedReference
+
+	return nil
+}
+
+// ResolveReferences of this PostgresBackup
+func (mg *PostgresBackup) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: reference.FromPtrValue(mg.Spec.ForProvider.PostgresName),
+		Reference:    mg.Spec.ForProvider.PostgresNameRef,
+		Selector:     mg.Spec.ForProvider.PostgresNameSelector,
+		To:           reference.To{Managed: &Postgres{}, List: &PostgresList{}},
+		Extract:      PostgresName(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.postgresName")
+	}
+	mg.Spec.ForProvider.PostgresName = reference.ToPtrValue(rsp.ResolvedValue)
+	mg.Spec.ForProvider.PostgresNameRef = rsp.ResolvedReference
+
+	return nil
+}
+
+// ResolveReferences of this PostgresSnapshot
+func (mg *PostgresSnapshot) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: reference.FromPtrValue(mg.Spec.ForProvider.PostgresName),
+		Reference:    mg.Spec.ForProvider.PostgresNameRef,
+		Selector:     mg.Spec.ForProvider.PostgresNameSelector,
+		To:           reference.To{Managed: &Postgres{}, List: &PostgresList{}},
+		Extract:      PostgresName(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.postgresName")
+	}
+	mg.Spec.ForProvider.PostgresName = reference.ToPtrValue(rsp.ResolvedValue)
+	mg.Spec.ForProvider.PostgresNameRef = rsp.ResolvedReference
+
+	return nil
+}
+
+// ResolveReferences of this PostgresPooler
+func (mg *PostgresPooler) ResolveReferences(ctx context.Context, c client.Reader) error {
+	r := reference.NewAPIResolver(c, mg)
+
+	rsp, err := r.Resolve(ctx, reference.ResolutionRequest{
+		CurrentValue: reference.FromPtrValue(mg.Spec.ForProvider.PostgresName),
+		Reference:    mg.Spec.ForProvider.PostgresNameRef,
+		Selector:     mg.Spec.ForProvider.PostgresNameSelector,
+		To:           reference.To{Managed: &Postgres{}, List: &PostgresList{}},
+		Extract:      PostgresName(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "spec.forProvider.postgresName")
+	}
+	mg.Spec.ForProvider.PostgresName = reference.ToPtrValue(rsp.ResolvedValue)
+	mg.Spec.ForProvider.PostgresNameRef = rsp.ResolvedReference
+
+	return nil
+}
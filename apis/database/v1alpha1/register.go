@@ -45,6 +45,60 @@ var (
 	PostgresGroupVersionKind = SchemeGroupVersion.WithKind(PostgresKind)
 )
 
+// PostgresDatabase type metadata.
+var (
+	PostgresDatabaseKind             = reflect.TypeOf(PostgresDatabase{}).Name()
+	PostgresDatabaseGroupKind        = schema.GroupKind{Group: Group, Kind: PostgresDatabaseKind}.String()
+	PostgresDatabaseKindAPIVersion   = PostgresDatabaseKind + "." + SchemeGroupVersion.String()
+	PostgresDatabaseGroupVersionKind = SchemeGroupVersion.WithKind(PostgresDatabaseKind)
+)
+
+// PostgresRole type metadata.
+var (
+	PostgresRoleKind             = reflect.TypeOf(PostgresRole{}).Name()
+	PostgresRoleGroupKind        = schema.GroupKind{Group: Group, Kind: PostgresRoleKind}.String()
+	PostgresRoleKindAPIVersion   = PostgresRoleKind + "." + SchemeGroupVersion.String()
+	PostgresRoleGroupVersionKind = SchemeGroupVersion.WithKind(PostgresRoleKind)
+)
+
+// PostgresBackup type metadata.
+var (
+	PostgresBackupKind             = reflect.TypeOf(PostgresBackup{}).Name()
+	PostgresBackupGroupKind        = schema.GroupKind{Group: Group, Kind: PostgresBackupKind}.String()
+	PostgresBackupKindAPIVersion   = PostgresBackupKind + "." + SchemeGroupVersion.String()
+	PostgresBackupGroupVersionKind = SchemeGroupVersion.WithKind(PostgresBackupKind)
+)
+
+// PostgresSnapshot type metadata.
+var (
+	PostgresSnapshotKind             = reflect.TypeOf(PostgresSnapshot{}).Name()
+	PostgresSnapshotGroupKind        = schema.GroupKind{Group: Group, Kind: PostgresSnapshotKind}.String()
+	PostgresSnapshotKindAPIVersion   = PostgresSnapshotKind + "." + SchemeGroupVersion.String()
+	PostgresSnapshotGroupVersionKind = SchemeGroupVersion.WithKind(PostgresSnapshotKind)
+)
+
+// PostgresPooler type metadata.
+var (
+	PostgresPoolerKind             = reflect.TypeOf(PostgresPooler{}).Name()
+	PostgresPoolerGroupKind        = schema.GroupKind{Group: Group, Kind: PostgresPoolerKind}.String()
+	PostgresPoolerKindAPIVersion   = PostgresPoolerKind + "." + SchemeGroupVersion.String()
+	PostgresPoolerGroupVersionKind = SchemeGroupVersion.WithKind(PostgresPoolerKind)
+)
+
+// PostgresClass type metadata.
+var (
+	PostgresClassKind             = reflect.TypeOf(PostgresClass{}).Name()
+	PostgresClassGroupKind        = schema.GroupKind{Group: Group, Kind: PostgresClassKind}.String()
+	PostgresClassKindAPIVersion   = PostgresClassKind + "." + SchemeGroupVersion.String()
+	PostgresClassGroupVersionKind = SchemeGroupVersion.WithKind(PostgresClassKind)
+)
+
 func init() {
 	SchemeBuilder.Register(&Postgres{}, &PostgresList{})
+	SchemeBuilder.Register(&PostgresDatabase{}, &PostgresDatabaseList{})
+	SchemeBuilder.Register(&PostgresRole{}, &PostgresRoleList{})
+	SchemeBuilder.Register(&PostgresBackup{}, &PostgresBackupList{})
+	SchemeBuilder.Register(&PostgresSnapshot{}, &PostgresSnapshotList{})
+	SchemeBuilder.Register(&PostgresPooler{}, &PostgresPoolerList{})
+	SchemeBuilder.Register(&PostgresClass{}, &PostgresClassList{})
 }
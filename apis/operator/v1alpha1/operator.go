@@ -7,6 +7,23 @@ import (
 
 // OperatorParameters contains the user defined values for an operator.
 type OperatorParameters struct {
+	// Namespace is the namespace in the target cluster the operator is
+	// subscribed in. Defaults to default.
+	// +immutable
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+
+	// CreateNamespace makes the provider create the namespace if it does not
+	// exist and keep the NamespaceLabels on it. The namespace is not deleted
+	// with the operator.
+	// +optional
+	CreateNamespace *bool `json:"createNamespace,omitempty"`
+
+	// NamespaceLabels are the labels set on the namespace if CreateNamespace
+	// is true.
+	// +optional
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
+
 	// +immutable
 	OperatorName string `json:"operatorName"`
 
@@ -25,8 +25,29 @@ import (
 // A ProviderConfigSpec defines the desired state of a ProviderConfig.
 type ProviderConfigSpec struct {
 	v1alpha1.ProviderConfigSpec `json:",inline"`
+
+	// PodSecurityProfile is the security profile of the pods created for the
+	// database kinds. Restricted runs them as a fixed non-root user without
+	// capabilities, with the RuntimeDefault seccomp profile and a read-only
+	// root filesystem where possible, as required by the restricted Pod
+	// Security Standard. The seccomp profile is set with the annotation
+	// deprecated in Kubernetes 1.19, which recent releases ignore. Default
+	// leaves the security context of the pods to the images. Defaults to
+	// Default.
+	// +optional
+	PodSecurityProfile *PodSecurityProfile `json:"podSecurityProfile,omitempty"`
 }
 
+// PodSecurityProfile is the security profile of the pods created by the
+// provider.
+type PodSecurityProfile string
+
+// The security profiles of the pods created by the provider.
+const (
+	PodSecurityProfileDefault    PodSecurityProfile = "Default"
+	PodSecurityProfileRestricted PodSecurityProfile = "Restricted"
+)
+
 // A ProviderConfigStatus represents the status of a ProviderConfig.
 type ProviderConfigStatus struct {
 	v1alpha1.ProviderConfigStatus `json:",inline"`
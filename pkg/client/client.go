@@ -24,6 +24,8 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -43,6 +45,8 @@ const (
 	errFailedToCreateRestConfig          = "cannot create new rest config using provider secret"
 	errProviderSecretValueForKeyNotFound = "value for key \"%s\" not found in provider credentials secret"
 	errFmtUnsupportedCredSource          = "unsupported credentials source %q"
+	errGetNamespace                      = "failed to get namespace %q"
+	errCreateNamespace                   = "failed to create or update namespace %q"
 )
 
 // NewRestConfig returns a rest config given a secret with connection information.
@@ -113,8 +117,9 @@ func GetSecretData(ctx context.Context, kube client.Client, nn types.NamespacedN
 	return s.Data, nil
 }
 
-// GetProviderConfigRC gets the provider config secret, and parses it into a rest.Config
-func GetProviderConfigRC(ctx context.Context, cr resource.Managed, kube client.Client) (*rest.Config, error) { //nolint:gocyclo
+// GetProviderConfig gets the provider config referenced by the given managed
+// resource
+func GetProviderConfig(ctx context.Context, cr resource.Managed, kube client.Client) (*v1beta1.ProviderConfig, error) {
 	p := &v1beta1.ProviderConfig{}
 
 	if cr.GetProviderConfigReference() == nil {
@@ -125,7 +130,21 @@ func GetProviderConfigRC(ctx context.Context, cr resource.Managed, kube client.C
 	if err := kube.Get(ctx, n, p); err != nil {
 		return nil, errors.Wrap(err, errProviderNotRetrieved)
 	}
+	return p, nil
+}
+
+// GetProviderConfigRC gets the provider config secret, and parses it into a rest.Config
+func GetProviderConfigRC(ctx context.Context, cr resource.Managed, kube client.Client) (*rest.Config, error) {
+	p, err := GetProviderConfig(ctx, cr, kube)
+	if err != nil {
+		return nil, err
+	}
+	return ProviderConfigRC(ctx, p, kube)
+}
 
+// ProviderConfigRC parses the credentials of the given provider config into a
+// rest.Config
+func ProviderConfigRC(ctx context.Context, p *v1beta1.ProviderConfig, kube client.Client) (*rest.Config, error) { //nolint:gocyclo
 	s := p.Spec.Credentials.Source
 	switch s { //nolint:exhaustive
 	case runtimev1alpha1.CredentialsSourceInjectedIdentity:
@@ -158,3 +177,80 @@ func GetProviderConfigRC(ctx context.Context, cr resource.Managed, kube client.C
 		return nil, errors.Errorf(errFmtUnsupportedCredSource, s)
 	}
 }
+
+// PodSecurityProfile returns the security profile of the pods created for the
+// managed resources using the given provider config
+func PodSecurityProfile(p *v1beta1.ProviderConfig) v1beta1.PodSecurityProfile {
+	if p.Spec.PodSecurityProfile == nil {
+		return v1beta1.PodSecurityProfileDefault
+	}
+	return *p.Spec.PodSecurityProfile
+}
+
+// IsNamespaceUpToDate checks whether the namespace with the given name exists
+// and carries the given labels
+func IsNamespaceUpToDate(ctx context.Context, kube client.Reader, name string, labels map[string]string) (bool, error) {
+	ns := &corev1.Namespace{}
+	err := kube.Get(ctx, types.NamespacedName{Name: name}, ns)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, errGetNamespace, name)
+	}
+	for k, v := range labels {
+		if l, ok := ns.Labels[k]; !ok || l != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// EnsureNamespace creates the namespace with the given name and labels, or
+// adds the labels to the existing namespace. Other labels are left alone.
+func EnsureNamespace(ctx context.Context, kube client.Client, name string, labels map[string]string) error {
+	ns := &corev1.Namespace{}
+	err := kube.Get(ctx, types.NamespacedName{Name: name}, ns)
+	if kerrors.IsNotFound(err) {
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+		return errors.Wrapf(kube.Create(ctx, ns), errCreateNamespace, name)
+	}
+	if err != nil {
+		return errors.Wrapf(err, errGetNamespace, name)
+	}
+	changed := false
+	for k, v := range labels {
+		if l, ok := ns.Labels[k]; !ok || l != v {
+			if ns.Labels == nil {
+				ns.Labels = map[string]string{}
+			}
+			ns.Labels[k] = v
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return errors.Wrapf(kube.Update(ctx, ns), errCreateNamespace, name)
+}
+
+// SameProviderConfig returns true if the given managed resources use the same
+// provider config, i.e. create their objects in the same cluster
+func SameProviderConfig(a, b resource.Managed) bool {
+	ra, rb := a.GetProviderConfigReference(), b.GetProviderConfigReference()
+	if ra == nil || rb == nil {
+		return ra == rb
+	}
+	return ra.Name == rb.Name
+}
+
+// CreatedBefore returns true if a was created before b. Objects created in the
+// same second are ordered by name, so that exactly one of two conflicting
+// objects takes precedence.
+func CreatedBefore(a, b metav1.Object) bool {
+	ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !ta.Equal(&tb) {
+		return ta.Before(&tb)
+	}
+	return a.GetName() < b.GetName()
+}
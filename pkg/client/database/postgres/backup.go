@@ -0,0 +1,324 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errBackupDestination = "exactly one of pvc and s3 has to be set as backup destination"
+	errNoInstance        = "postgres instance %s does not exist yet"
+
+	// LabelBackup marks the Jobs and pods of a PostgresBackup
+	LabelBackup = "in-cluster.crossplane.io/backup"
+	// ImageS3Upload is the image used to upload backups to S3
+	ImageS3Upload = "amazon/aws-cli"
+	// DefaultBackupRetention is the number of backups kept if none is set
+	DefaultBackupRetention = 7
+	// BackupContainerName is the name of the container reporting the location
+	// and size of a backup in its termination message
+	BackupContainerName = "backup"
+
+	labelJobName      = "job-name"
+	backupVolumeName  = "backup"
+	backupMountPath   = "/backup"
+	dumpContainerName = "dump"
+)
+
+// dumpScript dumps the database, or all databases if PGDATABASE is empty, into
+// a new file in BACKUP_DIR named after the current time
+const dumpScript = `set -eo pipefail
+mkdir -p "$BACKUP_DIR"
+name="$(date -u +%Y%m%dT%H%M%SZ)"
+if [ -n "$PGDATABASE" ]; then
+  file="$name.dump"
+  pg_dump -Fc -f "$BACKUP_DIR/$file.partial"
+else
+  file="$name.sql.gz"
+  pg_dumpall | gzip > "$BACKUP_DIR/$file.partial"
+fi
+mv "$BACKUP_DIR/$file.partial" "$BACKUP_DIR/$file"
+`
+
+// backupFilePattern matches the files written by dumpScript
+const backupFilePattern = `^[0-9]{8}T[0-9]{6}Z\.(dump|sql\.gz)$`
+
+// pvcBackupScript dumps into the backup PVC, deletes all but the last
+// RETENTION backups and reports the path of the backup inside the claim and
+// its size
+const pvcBackupScript = dumpScript + `ls -1 "$BACKUP_DIR" | sort -r | while read -r old; do
+  if [[ "$old" =~ ` + backupFilePattern + ` ]]; then echo "$old"; fi
+done | tail -n +$((RETENTION + 1)) | while read -r old; do rm -f "$BACKUP_DIR/$old"; done
+echo "${BACKUP_DIR#` + backupMountPath + `/}/$file $(stat -c %s "$BACKUP_DIR/$file")" > /dev/termination-log
+`
+
+// s3UploadScript uploads the backup dumped into an emptyDir to S3_URL, deletes
+// all but the last RETENTION backups and reports the URL of the backup and its
+// size
+const s3UploadScript = `set -eo pipefail
+s3() {
+  if [ -n "$S3_ENDPOINT" ]; then aws --endpoint-url "$S3_ENDPOINT" s3 "$@"; else aws s3 "$@"; fi
+}
+file="$(ls -1 "$BACKUP_DIR")"
+s3 cp "$BACKUP_DIR/$file" "$S3_URL/$file"
+s3 ls "$S3_URL/" | while read -r _ _ _ old; do
+  if [[ "$old" =~ ` + backupFilePattern + ` ]]; then echo "$old"; fi
+done | sort -r | tail -n +$((RETENTION + 1)) | while read -r old; do s3 rm "$S3_URL/$old"; done
+echo "$S3_URL/$file $(stat -c %s "$BACKUP_DIR/$file")" > /dev/termination-log
+`
+
+// BackupName returns the name of the CronJob taking the backups of the given
+// PostgresBackup
+func BackupName(cr *v1alpha1.PostgresBackup) string {
+	return cr.Name + "-backup"
+}
+
+// BackupPath returns the directory in the PVC or the key prefix in the bucket
+// the backups are written to
+func BackupPath(cr *v1alpha1.PostgresBackup) string {
+	d := cr.Spec.ForProvider.Destination
+	switch {
+	case d.PVC != nil:
+		return utils.StringValueFallback(d.PVC.Path, cr.Name)
+	case d.S3 != nil:
+		return utils.StringValueFallback(d.S3.Prefix, cr.Name)
+	}
+	return ""
+}
+
+// MasterUsername returns the name of the master user of the given instance
+func MasterUsername(ps *v1alpha1.Postgres) string {
+	return utils.StringValueFallback(ps.Spec.ForProvider.MasterUsername, DefaultMasterUsername)
+}
+
+// InstancePassword returns the master password of the running instance. It is
+// read from the password Secret of the instance, or from the pod template of
+// instances created before the Secret was introduced.
+func InstancePassword(ctx context.Context, kube client.Reader, ps *v1alpha1.Postgres) (string, error) {
+	s := &v1.Secret{}
+	err := kube.Get(ctx, types.NamespacedName{Name: PasswordSecretName(ps), Namespace: ps.Namespace}, s)
+	if err == nil {
+		return string(s.Data[PasswordSecretKey]), nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return "", err
+	}
+	nn := types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}
+	if IsReplicated(ps) {
+		sts := &appsv1.StatefulSet{}
+		if err := kube.Get(ctx, nn, sts); err != nil {
+			return "", instanceError(err, ps)
+		}
+		return PasswordFromPodTemplate(sts.Spec.Template), nil
+	}
+	dpl := &appsv1.Deployment{}
+	if err := kube.Get(ctx, nn, dpl); err != nil {
+		return "", instanceError(err, ps)
+	}
+	return PasswordFromPodTemplate(dpl.Spec.Template), nil
+}
+
+func instanceError(err error, ps *v1alpha1.Postgres) error {
+	if kerrors.IsNotFound(err) {
+		return errors.Errorf(errNoInstance, ps.Name)
+	}
+	return err
+}
+
+// MakeBackupCronJob creates the CronJob dumping the given instance on the
+// schedule of the given PostgresBackup. pg_dump connects to the primary
+// Service of the instance with the master user, whose password is taken from
+// the password Secret of the instance.
+func MakeBackupCronJob(cr *v1alpha1.PostgresBackup, ps *v1alpha1.Postgres) (*batchv1beta1.CronJob, error) {
+	p := cr.Spec.ForProvider
+	if (p.Destination.PVC == nil) == (p.Destination.S3 == nil) {
+		return nil, errors.New(errBackupDestination)
+	}
+	labels := map[string]string{LabelBackup: cr.Name}
+	env := []v1.EnvVar{
+		envVarFromValue("PGHOST", PrimaryHost(ps)),
+		envVarFromValue("PGPORT", strconv.Itoa(Port(ps))),
+		envVarFromValue("PGUSER", MasterUsername(ps)),
+		envVarFromSecret("PGPASSWORD", PasswordSecretName(ps), PasswordSecretKey),
+		envVarFromValue("RETENTION", strconv.Itoa(BackupRetention(cr))),
+	}
+	if p.Database != nil {
+		env = append(env, envVarFromValue("PGDATABASE", *p.Database))
+	}
+
+	var spec v1.PodSpec
+	if p.Destination.PVC != nil {
+		spec = v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name: backupVolumeName,
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: p.Destination.PVC.ClaimName},
+				},
+			}},
+			Containers: []v1.Container{{
+				Name:         BackupContainerName,
+				Image:        Image(ps),
+				Command:      []string{"/bin/bash", "-c", pvcBackupScript},
+				Env:          append(env, envVarFromValue("BACKUP_DIR", backupMountPath+"/"+BackupPath(cr))),
+				VolumeMounts: []v1.VolumeMount{{Name: backupVolumeName, MountPath: backupMountPath}},
+			}},
+		}
+	} else {
+		s3 := p.Destination.S3
+		env = append(env, envVarFromValue("BACKUP_DIR", backupMountPath))
+		uploadEnv := []v1.EnvVar{
+			envVarFromValue("BACKUP_DIR", backupMountPath),
+			envVarFromValue("RETENTION", strconv.Itoa(BackupRetention(cr))),
+			envVarFromValue("S3_URL", "s3://"+s3.Bucket+"/"+BackupPath(cr)),
+		}
+		if s3.Endpoint != nil {
+			uploadEnv = append(uploadEnv, envVarFromValue("S3_ENDPOINT", *s3.Endpoint))
+		}
+		if s3.Region != nil {
+			uploadEnv = append(uploadEnv, envVarFromValue("AWS_DEFAULT_REGION", *s3.Region))
+		}
+		spec = v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name:         backupVolumeName,
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			}},
+			InitContainers: []v1.Container{{
+				Name:         dumpContainerName,
+				Image:        Image(ps),
+				Command:      []string{"/bin/bash", "-c", dumpScript},
+				Env:          env,
+				VolumeMounts: []v1.VolumeMount{{Name: backupVolumeName, MountPath: backupMountPath}},
+			}},
+			Containers: []v1.Container{{
+				Name:    BackupContainerName,
+				Image:   ImageS3Upload,
+				Command: []string{"/bin/bash", "-c", s3UploadScript},
+				Env:     uploadEnv,
+				EnvFrom: []v1.EnvFromSource{{
+					SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: s3.CredentialsSecretName}},
+				}},
+				VolumeMounts: []v1.VolumeMount{{Name: backupVolumeName, MountPath: backupMountPath}},
+			}},
+		}
+	}
+	spec.RestartPolicy = v1.RestartPolicyNever
+
+	meta := metav1.ObjectMeta{Name: BackupName(cr), Namespace: ps.Namespace, Labels: map[string]string{}}
+	for k, v := range labels {
+		meta.Labels[k] = v
+	}
+	clients.SetOwner(&meta, cr, v1alpha1.PostgresBackupGroupVersionKind)
+	cj := &batchv1beta1.CronJob{
+		ObjectMeta: meta,
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:          p.Schedule,
+			Suspend:           utils.Bool(utils.BoolValueFallback(p.Suspend, false)),
+			ConcurrencyPolicy: batchv1beta1.ForbidConcurrent,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: utils.Int32(2),
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec:       spec,
+					},
+				},
+			},
+		},
+	}
+	restrictPodSecurity(cr.Status.AtProvider.PodSecurityProfile, &cj.Spec.JobTemplate.Spec.Template, true)
+	return cj, nil
+}
+
+// BackupRetention returns the number of backups kept for the given
+// PostgresBackup
+func BackupRetention(cr *v1alpha1.PostgresBackup) int {
+	if cr.Spec.ForProvider.Retention == nil {
+		return DefaultBackupRetention
+	}
+	return *cr.Spec.ForProvider.Retention
+}
+
+// IsCronJobUpToDate checks whether the observed CronJob still matches the
+// desired one
+func IsCronJobUpToDate(desired, observed *batchv1beta1.CronJob) bool {
+	return equality.Semantic.DeepDerivative(desired.Spec, observed.Spec) &&
+		hasPodSecurity(desired.Spec.JobTemplate.Spec.Template, observed.Spec.JobTemplate.Spec.Template)
+}
+
+// LastSuccessfulJob returns the Job which completed last among the given
+// ones, or nil if none of them succeeded
+func LastSuccessfulJob(jobs []batchv1.Job) *batchv1.Job {
+	var last *batchv1.Job
+	for i := range jobs {
+		j := &jobs[i]
+		if j.Status.Succeeded == 0 || j.Status.CompletionTime == nil {
+			continue
+		}
+		if last == nil || last.Status.CompletionTime.Before(j.Status.CompletionTime) {
+			last = j
+		}
+	}
+	return last
+}
+
+// BackupReport returns the location and size of the backup a Job took as
+// reported by one of its pods. ok is false if none of the pods reported a
+// backup.
+func BackupReport(pods []v1.Pod) (location string, size int64, ok bool) {
+	for _, pod := range pods {
+		for _, s := range pod.Status.ContainerStatuses {
+			t := s.State.Terminated
+			if s.Name != BackupContainerName || t == nil || t.ExitCode != 0 {
+				continue
+			}
+			fields := strings.Fields(t.Message)
+			if len(fields) != 2 {
+				continue
+			}
+			size, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			return fields[0], size, true
+		}
+	}
+	return "", 0, false
+}
+
+// JobPodSelector selects the pods of the given Job
+func JobPodSelector(job *batchv1.Job) client.MatchingLabels {
+	return client.MatchingLabels{labelJobName: job.Name}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+)
+
+const (
+	// ConfigFileKey is the key of postgresql.conf in the config ConfigMap
+	ConfigFileKey = "postgresql.conf"
+	// AnnotationRestartParameters records the parameters a pod was started
+	// with which Postgres only reads at start
+	AnnotationRestartParameters = "in-cluster.crossplane.io/restart-parameters"
+
+	errInvalidParameter = "invalid parameter name %q"
+	errParameterValue   = "invalid value of parameter %s, it must not contain line breaks"
+	errManagedParameter = "parameter %s is set by the provider and cannot be changed"
+
+	configVolumeName = "config"
+	configMountPath  = "/etc/postgresql"
+	configFilePath   = configMountPath + "/" + ConfigFileKey
+	configStale      = "stale"
+)
+
+// configTemplate is the postgresql.conf of an instance. It includes the file
+// created by initdb in the data directory, so that only the parameters set by
+// the provider and the user are overridden.
+const configTemplate = `# managed by provider-in-cluster, changes are overwritten
+include_if_exists = '%s/postgresql.conf'
+listen_addresses = '*'
+`
+
+var parameterName = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// managedParameters are set by the provider
+var managedParameters = map[string]bool{
+	"config_file":       true,
+	"data_directory":    true,
+	"external_pid_file": true,
+	"hba_file":          true,
+	"ident_file":        true,
+	"listen_addresses":  true,
+	"port":              true,
+	"primary_conninfo":  true,
+	"ssl":               true,
+	"ssl_cert_file":     true,
+	"ssl_key_file":      true,
+}
+
+// restartParameters are the parameters Postgres only reads at server start,
+// i.e. those in the postmaster context of pg_settings. Changing any other
+// parameter takes effect on reload.
+var restartParameters = map[string]bool{
+	"archive_mode":                        true,
+	"autovacuum_freeze_max_age":           true,
+	"autovacuum_max_workers":              true,
+	"autovacuum_multixact_freeze_max_age": true,
+	"bonjour":                             true,
+	"bonjour_name":                        true,
+	"cluster_name":                        true,
+	"data_sync_retry":                     true,
+	"dynamic_shared_memory_type":          true,
+	"event_source":                        true,
+	"hot_standby":                         true,
+	"huge_pages":                          true,
+	"jit_provider":                        true,
+	"logging_collector":                   true,
+	"max_connections":                     true,
+	"max_files_per_process":               true,
+	"max_locks_per_transaction":           true,
+	"max_logical_replication_workers":     true,
+	"max_pred_locks_per_transaction":      true,
+	"max_prepared_transactions":           true,
+	"max_replication_slots":               true,
+	"max_wal_senders":                     true,
+	"max_worker_processes":                true,
+	"old_snapshot_threshold":              true,
+	"pg_stat_statements.max":              true,
+	"primary_slot_name":                   true,
+	"recovery_target":                     true,
+	"recovery_target_action":              true,
+	"recovery_target_inclusive":           true,
+	"recovery_target_lsn":                 true,
+	"recovery_target_name":                true,
+	"recovery_target_time":                true,
+	"recovery_target_timeline":            true,
+	"recovery_target_xid":                 true,
+	"restore_command":                     true,
+	"shared_buffers":                      true,
+	"shared_memory_type":                  true,
+	"shared_preload_libraries":            true,
+	"superuser_reserved_connections":      true,
+	"track_activity_query_size":           true,
+	"track_commit_timestamp":              true,
+	"unix_socket_directories":             true,
+	"unix_socket_group":                   true,
+	"unix_socket_permissions":             true,
+	"wal_buffers":                         true,
+	"wal_level":                           true,
+	"wal_log_hints":                       true,
+}
+
+// IsRestartParameter returns true if Postgres only reads the given parameter
+// at server start
+func IsRestartParameter(name string) bool {
+	return restartParameters[name]
+}
+
+// ValidateParameters checks that the parameters of the given instance can be
+// written to postgresql.conf and are not set by the provider
+func ValidateParameters(ps *v1alpha1.Postgres) error {
+	for _, name := range sortedKeys(ps.Spec.ForProvider.Parameters) {
+		switch {
+		case !parameterName.MatchString(name):
+			return errors.Errorf(errInvalidParameter, name)
+		case managedParameters[name]:
+			return errors.Errorf(errManagedParameter, name)
+		case strings.ContainsAny(ps.Spec.ForProvider.Parameters[name], "\r\n"):
+			return errors.Errorf(errParameterValue, name)
+		}
+	}
+	return nil
+}
+
+// ConfigMapName returns the name of the ConfigMap holding the postgresql.conf
+// of the given instance
+func ConfigMapName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-config"
+}
+
+// MakePostgresConfigMap creates the ConfigMap holding the postgresql.conf of
+// the given instance, and its pg_hba.conf if it serves TLS connections
+func MakePostgresConfigMap(ps *v1alpha1.Postgres) *v1.ConfigMap {
+	conf := &strings.Builder{}
+	fmt.Fprintf(conf, configTemplate, DataDirectory(Version(ps)))
+	if ps.Spec.ForProvider.TLS != nil {
+		conf.WriteString(tlsConfig)
+	}
+	params := Parameters(ps)
+	for _, name := range sortedKeys(params) {
+		fmt.Fprintf(conf, "%s = %s\n", name, QuoteLiteral(params[name]))
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: objectMeta(ps, ConfigMapName(ps)),
+		Data: map[string]string{
+			ConfigFileKey: conf.String(),
+		},
+	}
+	if ps.Spec.ForProvider.TLS != nil {
+		cm.Data[HBAFileKey] = MakeHBAConfig(ps)
+	}
+	return cm
+}
+
+// ConfigChecksum returns the checksum of the postgresql.conf and the
+// pg_hba.conf in the given ConfigMap
+func ConfigChecksum(cm *v1.ConfigMap) string {
+	sum := sha256.Sum256([]byte(cm.Data[ConfigFileKey] + cm.Data[HBAFileKey]))
+	return hex.EncodeToString(sum[:])
+}
+
+// RestartParameters renders the parameters of the given instance which only
+// take effect on restart, one name=value per line. It is recorded on the pods
+// so that a change rolls them.
+func RestartParameters(ps *v1alpha1.Postgres) string {
+	var lines []string
+	params := Parameters(ps)
+	for _, name := range sortedKeys(params) {
+		if IsRestartParameter(name) {
+			lines = append(lines, name+"="+params[name])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PendingRestart returns the names of the parameters whose value differs
+// between the desired and the observed RestartParameters
+func PendingRestart(desired, observed string) []string {
+	d, o := parseRestartParameters(desired), parseRestartParameters(observed)
+	var names []string
+	for name, value := range d {
+		if v, ok := o[name]; !ok || v != value {
+			names = append(names, name)
+		}
+	}
+	for name := range o {
+		if _, ok := d[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseRestartParameters(s string) map[string]string {
+	params := map[string]string{}
+	for _, l := range strings.Split(s, "\n") {
+		if kv := strings.SplitN(l, "=", 2); len(kv) == 2 {
+			params[kv[0]] = kv[1]
+		}
+	}
+	return params
+}
+
+// ReloadConfigCommand reloads the configuration of the instance running in a
+// pod once the postgresql.conf and pg_hba.conf mounted into it have the given
+// checksum. It only prints "stale" if the pod does not see the files yet, as
+// updates of a ConfigMap reach the pods with a delay.
+func ReloadConfigCommand(checksum string) string {
+	return fmt.Sprintf(`if [ "$(cat %s %s 2>/dev/null | sha256sum | cut -d ' ' -f 1)" != %s ]; then echo %s; exit 0; fi; %s`,
+		configFilePath, hbaFilePath, quoteShell(checksum), configStale, PsqlCommand("postgres", "SELECT pg_reload_conf()"))
+}
+
+// IsConfigStale returns true if the output of ReloadConfigCommand shows that
+// the pod did not see the current postgresql.conf yet
+func IsConfigStale(out string) bool {
+	return strings.TrimSpace(out) == configStale
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
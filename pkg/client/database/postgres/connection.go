@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+)
+
+const (
+	// ResourceCredentialsSecretDatabaseKey is the key for the connection secret database
+	ResourceCredentialsSecretDatabaseKey = "database"
+	// ResourceCredentialsSecretReaderEndpointKey is the key for the connection secret
+	// endpoint of the read-only replicas
+	ResourceCredentialsSecretReaderEndpointKey = "readerEndpoint"
+	// ResourceCredentialsSecretReaderPortKey is the key for the connection
+	// secret port of the read-only replicas
+	ResourceCredentialsSecretReaderPortKey = "readerPort"
+	// ResourceCredentialsSecretSSLModeKey is the key for the connection secret
+	// sslmode clients connect with
+	ResourceCredentialsSecretSSLModeKey = "sslmode"
+	// ResourceCredentialsSecretCAKey is the key for the connection secret CA
+	// bundle the certificate of the instance is verified with
+	ResourceCredentialsSecretCAKey = "ca.crt"
+	// ResourceCredentialsSecretHostKey is the key for the connection secret
+	// DNS name of the Service, which does not change when the Service is
+	// recreated
+	ResourceCredentialsSecretHostKey = "host"
+	// ResourceCredentialsSecretURIKey is the key for the connection secret
+	// postgresql:// URI including the credentials
+	ResourceCredentialsSecretURIKey = "uri"
+	// ResourceCredentialsSecretJDBCURLKey is the key for the connection secret
+	// JDBC URL, which does not include the credentials
+	ResourceCredentialsSecretJDBCURLKey = "jdbcUrl"
+
+	sslModeRequire  = "require"
+	sslModeVerifyCA = "verify-ca"
+
+	errConnectionTemplateMsg = "invalid template of connection detail %s"
+	errConnectionKeyName     = "invalid connection detail name %q, it may only contain letters, digits, '-', '_' and '.'"
+	errConnectionKeyReserved = "connection detail %s is set by the provider and cannot be overridden"
+)
+
+var connectionKeyName = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// reservedConnectionKeys are the connection details set by the provider
+var reservedConnectionKeys = map[string]bool{
+	runtimev1alpha1.ResourceCredentialsSecretEndpointKey: true,
+	runtimev1alpha1.ResourceCredentialsSecretPortKey:     true,
+	runtimev1alpha1.ResourceCredentialsSecretUserKey:     true,
+	runtimev1alpha1.ResourceCredentialsSecretPasswordKey: true,
+	ResourceCredentialsSecretDatabaseKey:                 true,
+	ResourceCredentialsSecretReaderEndpointKey:           true,
+	ResourceCredentialsSecretReaderPortKey:               true,
+	ResourceCredentialsSecretSSLModeKey:                  true,
+	ResourceCredentialsSecretCAKey:                       true,
+	ResourceCredentialsSecretHostKey:                     true,
+	ResourceCredentialsSecretURIKey:                      true,
+	ResourceCredentialsSecretJDBCURLKey:                  true,
+}
+
+// ValidateConnectionTemplates checks that the additional connection details
+// of the given instance have valid names and templates
+func ValidateConnectionTemplates(ps *v1alpha1.Postgres) error {
+	for _, key := range additionalConnectionKeys(ps) {
+		switch {
+		case !connectionKeyName.MatchString(key):
+			return errors.Errorf(errConnectionKeyName, key)
+		case reservedConnectionKeys[key]:
+			return errors.Errorf(errConnectionKeyReserved, key)
+		}
+		if _, err := parseConnectionTemplate(key, ps.Spec.ForProvider.AdditionalConnectionDetails[key]); err != nil {
+			return errors.Wrapf(err, errConnectionTemplateMsg, key)
+		}
+	}
+	return nil
+}
+
+// AddTLSConnectionDetails adds the sslmode and the CA of an instance with
+// TLS, whose certificate is held by the given Secret, to the given
+// connection details. Clients verify the CA but not the hostname, as the
+// endpoint published is usually an IP, a load balancer address or a pooler
+// the certificate is not issued for.
+func AddTLSConnectionDetails(details managed.ConnectionDetails, ps *v1alpha1.Postgres, s *v1.Secret) managed.ConnectionDetails {
+	if ps.Spec.ForProvider.TLS == nil || s == nil {
+		return details
+	}
+	details[ResourceCredentialsSecretSSLModeKey] = []byte(sslModeRequire)
+	if ca := s.Data[TLSCAKey]; len(ca) > 0 {
+		details[ResourceCredentialsSecretSSLModeKey] = []byte(sslModeVerifyCA)
+		details[ResourceCredentialsSecretCAKey] = ca
+	}
+	return details
+}
+
+// CompleteConnectionDetails adds the URI and the JDBC URL of the given host
+// and port to the given connection details of the instance and renders the
+// additional connection details of the instance over them. The URLs are left
+// out while the host is not known yet. Instances and their poolers publish
+// the same details, they only differ in the address.
+func CompleteConnectionDetails(ps *v1alpha1.Postgres, details managed.ConnectionDetails, host, port string) (managed.ConnectionDetails, error) {
+	if host != "" {
+		addr := net.JoinHostPort(host, port)
+		db := string(details[ResourceCredentialsSecretDatabaseKey])
+		query := ""
+		if mode := details[ResourceCredentialsSecretSSLModeKey]; len(mode) > 0 {
+			query = url.Values{"sslmode": {string(mode)}}.Encode()
+		}
+		uri := url.URL{
+			Scheme:   "postgresql",
+			User:     url.UserPassword(string(details[runtimev1alpha1.ResourceCredentialsSecretUserKey]), string(details[runtimev1alpha1.ResourceCredentialsSecretPasswordKey])),
+			Host:     addr,
+			Path:     "/" + db,
+			RawQuery: query,
+		}
+		details[ResourceCredentialsSecretURIKey] = []byte(uri.String())
+		jdbc := "jdbc:postgresql://" + addr + "/" + url.PathEscape(db)
+		if query != "" {
+			jdbc += "?" + query
+		}
+		details[ResourceCredentialsSecretJDBCURLKey] = []byte(jdbc)
+	}
+
+	values := make(map[string]string, len(details))
+	for k, v := range details {
+		values[k] = string(v)
+	}
+	for _, key := range additionalConnectionKeys(ps) {
+		tpl, err := parseConnectionTemplate(key, ps.Spec.ForProvider.AdditionalConnectionDetails[key])
+		if err != nil {
+			return nil, errors.Wrapf(err, errConnectionTemplateMsg, key)
+		}
+		out := &strings.Builder{}
+		if err := tpl.Execute(out, values); err != nil {
+			return nil, errors.Wrapf(err, errConnectionTemplateMsg, key)
+		}
+		details[key] = []byte(out.String())
+	}
+	return details, nil
+}
+
+// parseConnectionTemplate parses the template of an additional connection
+// detail. Connection details which are not known yet render as empty
+// strings.
+func parseConnectionTemplate(key, text string) (*template.Template, error) {
+	return template.New(key).Option("missingkey=zero").Parse(text)
+}
+
+func additionalConnectionKeys(ps *v1alpha1.Postgres) []string {
+	keys := make([]string, 0, len(ps.Spec.ForProvider.AdditionalConnectionDetails))
+	for k := range ps.Spec.ForProvider.AdditionalConnectionDetails {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+)
+
+const (
+	// ObserveExtensionsQuery lists the extensions installed in a database
+	ObserveExtensionsQuery = "SELECT extname, extversion FROM pg_extension ORDER BY extname"
+	// SharedPreloadLibraries is the parameter listing the libraries Postgres
+	// loads at start, some extensions need theirs listed to work
+	SharedPreloadLibraries = "shared_preload_libraries"
+
+	errUnexpectedExtension = "unexpected row describing postgres extension: %q"
+	errDuplicateExtension  = "extension %s is listed more than once"
+)
+
+// preloadLibraries are the libraries extensions need in
+// shared_preload_libraries to work
+var preloadLibraries = map[string]string{
+	"citus":              "citus",
+	"pg_cron":            "pg_cron",
+	"pg_stat_statements": "pg_stat_statements",
+	"pg_wait_sampling":   "pg_wait_sampling",
+	"pgaudit":            "pgaudit",
+	"pglogical":          "pglogical",
+	"timescaledb":        "timescaledb",
+}
+
+// ValidateExtensions checks that no extension is listed twice
+func ValidateExtensions(extensions []v1alpha1.PostgresExtension) error {
+	seen := map[string]bool{}
+	for _, ext := range extensions {
+		if seen[ext.Name] {
+			return errors.Errorf(errDuplicateExtension, ext.Name)
+		}
+		seen[ext.Name] = true
+	}
+	return nil
+}
+
+// PreloadLibraries returns the libraries the extensions of the given instance
+// need in shared_preload_libraries
+func PreloadLibraries(ps *v1alpha1.Postgres) []string {
+	var libs []string
+	for _, ext := range ps.Spec.ForProvider.Extensions {
+		if lib, ok := preloadLibraries[ext.Name]; ok {
+			libs = append(libs, lib)
+		}
+	}
+	sort.Strings(libs)
+	return libs
+}
+
+// Parameters returns the postgresql.conf settings of the given instance. The
+// libraries needed by its extensions are added to shared_preload_libraries.
+func Parameters(ps *v1alpha1.Postgres) map[string]string {
+	libs := PreloadLibraries(ps)
+	if len(libs) == 0 {
+		return ps.Spec.ForProvider.Parameters
+	}
+	params := make(map[string]string, len(ps.Spec.ForProvider.Parameters)+1)
+	for k, v := range ps.Spec.ForProvider.Parameters {
+		params[k] = v
+	}
+	var preload []string
+	loaded := map[string]bool{}
+	for _, lib := range strings.Split(params[SharedPreloadLibraries], ",") {
+		if lib = strings.TrimSpace(lib); lib != "" {
+			preload = append(preload, lib)
+			loaded[lib] = true
+		}
+	}
+	for _, lib := range libs {
+		if !loaded[lib] {
+			preload = append(preload, lib)
+		}
+	}
+	params[SharedPreloadLibraries] = strings.Join(preload, ",")
+	return params
+}
+
+// ParseExtensions parses the output of ObserveExtensionsQuery into the
+// installed version of each extension
+func ParseExtensions(out string) (map[string]string, error) {
+	installed := map[string]string{}
+	for _, row := range SplitRows(out) {
+		if len(row) != 2 {
+			return nil, errors.Errorf(errUnexpectedExtension, strings.Join(row, "|"))
+		}
+		installed[row[0]] = row[1]
+	}
+	return installed, nil
+}
+
+// ExtensionStatements returns the statements creating, updating and dropping
+// extensions so that the installed ones match the desired ones. Only
+// extensions which were managed before, i.e. are listed in observed, are
+// dropped, so that extensions installed otherwise are left alone.
+func ExtensionStatements(desired []v1alpha1.PostgresExtension, observed []v1alpha1.PostgresExtensionStatus, installed map[string]string) []string {
+	var stmts []string
+	wanted := map[string]bool{}
+	for _, ext := range desired {
+		wanted[ext.Name] = true
+		version, ok := installed[ext.Name]
+		switch {
+		case !ok:
+			stmt := "CREATE EXTENSION IF NOT EXISTS " + QuoteIdentifier(ext.Name)
+			if ext.Schema != nil {
+				stmt += " SCHEMA " + QuoteIdentifier(*ext.Schema)
+			}
+			if ext.Version != nil {
+				stmt += " VERSION " + QuoteLiteral(*ext.Version)
+			}
+			stmts = append(stmts, stmt+" CASCADE")
+		case ext.Version != nil && *ext.Version != version:
+			stmts = append(stmts, fmt.Sprintf("ALTER EXTENSION %s UPDATE TO %s", QuoteIdentifier(ext.Name), QuoteLiteral(*ext.Version)))
+		}
+	}
+	for _, ext := range observed {
+		if _, ok := installed[ext.Name]; ok && !wanted[ext.Name] {
+			stmts = append(stmts, "DROP EXTENSION IF EXISTS "+QuoteIdentifier(ext.Name))
+		}
+	}
+	return stmts
+}
+
+// ExtensionStatus returns the installed versions of the desired extensions
+// and of those managed before which are still installed
+func ExtensionStatus(desired []v1alpha1.PostgresExtension, observed []v1alpha1.PostgresExtensionStatus, installed map[string]string) []v1alpha1.PostgresExtensionStatus {
+	names := map[string]bool{}
+	for _, ext := range desired {
+		names[ext.Name] = true
+	}
+	for _, ext := range observed {
+		names[ext.Name] = true
+	}
+	var status []v1alpha1.PostgresExtensionStatus
+	for name := range names {
+		if version, ok := installed[name]; ok {
+			status = append(status, v1alpha1.PostgresExtensionStatus{Name: name, Version: version})
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Name < status[j].Name })
+	return status
+}
@@ -19,6 +19,7 @@ package fake
 import (
 	"context"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -33,8 +34,24 @@ type MockPostgresClient struct {
 	MockCreateOrUpdate           func(ctx context.Context, postgres runtime.Object) (controllerutil.OperationResult, error)
 	MockParseInputSecret         func(ctx context.Context, postgres v1alpha1.Postgres) (string, error)
 	MockDeletePostgresPVC        func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDataClaims               func(ctx context.Context, postgres *v1alpha1.Postgres) ([]v1.PersistentVolumeClaim, error)
+	MockRetainPostgresPVC        func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockSnapshotPostgresPVC      func(ctx context.Context, postgres *v1alpha1.Postgres) ([]string, bool, error)
 	MockDeletePostgresDeployment func(ctx context.Context, postgres *v1alpha1.Postgres) error
 	MockDeletePostgresService    func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresUpgradeJob func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresRestoreJob func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresSecret     func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresSts        func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresRepl       func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresConfig     func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresTLSSecret  func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresMonitoring func(ctx context.Context, postgres *v1alpha1.Postgres) error
+	MockDeletePostgresPooler     func(ctx context.Context, cr *v1alpha1.PostgresPooler) error
+	MockSetPodRole               func(ctx context.Context, pod *v1.Pod, role string) error
+	MockDeletePod                func(ctx context.Context, pod *v1.Pod) error
+	MockExecInPod                func(ctx context.Context, pod *v1.Pod, container, cmd string) (string, error)
+	MockExecSQL                  func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error)
 	MockGeneratePassword         func() (string, error)
 }
 
@@ -53,6 +70,21 @@ func (c MockPostgresClient) DeletePostgresPVC(ctx context.Context, postgres *v1a
 	return c.MockDeletePostgresPVC(ctx, postgres)
 }
 
+// DataClaims calls the MockDataClaims fake function
+func (c MockPostgresClient) DataClaims(ctx context.Context, postgres *v1alpha1.Postgres) ([]v1.PersistentVolumeClaim, error) {
+	return c.MockDataClaims(ctx, postgres)
+}
+
+// RetainPostgresPVC calls the MockRetainPostgresPVC fake function
+func (c MockPostgresClient) RetainPostgresPVC(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockRetainPostgresPVC(ctx, postgres)
+}
+
+// SnapshotPostgresPVC calls the MockSnapshotPostgresPVC fake function
+func (c MockPostgresClient) SnapshotPostgresPVC(ctx context.Context, postgres *v1alpha1.Postgres) ([]string, bool, error) {
+	return c.MockSnapshotPostgresPVC(ctx, postgres)
+}
+
 // DeletePostgresDeployment calls the MockDeletePostgresDeployment fake function
 func (c MockPostgresClient) DeletePostgresDeployment(ctx context.Context, postgres *v1alpha1.Postgres) error {
 	return c.MockDeletePostgresDeployment(ctx, postgres)
@@ -63,7 +95,72 @@ func (c MockPostgresClient) DeletePostgresService(ctx context.Context, postgres
 	return c.MockDeletePostgresService(ctx, postgres)
 }
 
+// DeletePostgresUpgradeJob calls the MockDeletePostgresUpgradeJob fake function
+func (c MockPostgresClient) DeletePostgresUpgradeJob(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresUpgradeJob(ctx, postgres)
+}
+
+// DeletePostgresRestoreJob calls the MockDeletePostgresRestoreJob fake function
+func (c MockPostgresClient) DeletePostgresRestoreJob(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresRestoreJob(ctx, postgres)
+}
+
+// DeletePostgresPasswordSecret calls the MockDeletePostgresSecret fake function
+func (c MockPostgresClient) DeletePostgresPasswordSecret(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresSecret(ctx, postgres)
+}
+
+// DeletePostgresStatefulSet calls the MockDeletePostgresSts fake function
+func (c MockPostgresClient) DeletePostgresStatefulSet(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresSts(ctx, postgres)
+}
+
+// DeletePostgresReplication calls the MockDeletePostgresRepl fake function
+func (c MockPostgresClient) DeletePostgresReplication(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresRepl(ctx, postgres)
+}
+
+// DeletePostgresConfig calls the MockDeletePostgresConfig fake function
+func (c MockPostgresClient) DeletePostgresConfig(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresConfig(ctx, postgres)
+}
+
+// DeletePostgresTLSSecret calls the MockDeletePostgresTLSSecret fake function
+func (c MockPostgresClient) DeletePostgresTLSSecret(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresTLSSecret(ctx, postgres)
+}
+
+// DeletePostgresMonitoring calls the MockDeletePostgresMonitoring fake function
+func (c MockPostgresClient) DeletePostgresMonitoring(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	return c.MockDeletePostgresMonitoring(ctx, postgres)
+}
+
+// DeletePostgresPooler calls the MockDeletePostgresPooler fake function
+func (c MockPostgresClient) DeletePostgresPooler(ctx context.Context, cr *v1alpha1.PostgresPooler) error {
+	return c.MockDeletePostgresPooler(ctx, cr)
+}
+
+// SetPodRole calls the MockSetPodRole fake function
+func (c MockPostgresClient) SetPodRole(ctx context.Context, pod *v1.Pod, role string) error {
+	return c.MockSetPodRole(ctx, pod, role)
+}
+
 // CreateOrUpdate calls the MockCreateOrUpdate fake function
 func (c MockPostgresClient) CreateOrUpdate(ctx context.Context, postgres runtime.Object) (controllerutil.OperationResult, error) {
 	return c.MockCreateOrUpdate(ctx, postgres)
 }
+
+// DeletePod calls the MockDeletePod fake function
+func (c MockPostgresClient) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	return c.MockDeletePod(ctx, pod)
+}
+
+// ExecInPod calls the MockExecInPod fake function
+func (c MockPostgresClient) ExecInPod(ctx context.Context, pod *v1.Pod, container, cmd string) (string, error) {
+	return c.MockExecInPod(ctx, pod, container, cmd)
+}
+
+// ExecSQL calls the MockExecSQL fake function
+func (c MockPostgresClient) ExecSQL(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+	return c.MockExecSQL(ctx, ps, database, statements...)
+}
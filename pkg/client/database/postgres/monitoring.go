@@ -0,0 +1,292 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	// MonitoringRole is the role the metrics exporter connects as
+	MonitoringRole = "postgres_exporter"
+	// DefaultExporterImage is the image of the metrics exporter if none is set
+	DefaultExporterImage = "quay.io/prometheuscommunity/postgres-exporter:v0.8.0"
+	// DefaultExporterPort is the port the metrics are served on if none is set
+	DefaultExporterPort = 9187
+
+	exporterContainerName = "exporter"
+	metricsPortName       = "metrics"
+	metricsPath           = "/metrics"
+
+	errUnexpectedMonitoringRole = "unexpected row describing postgres monitoring role: %q"
+	errMonitoringReplicated     = "monitoring is not supported for replicated instances"
+)
+
+// ObserveMonitoringRoleQuery describes the monitoring role in a single row of
+// its password hash and whether it has the privileges of pg_monitor
+var ObserveMonitoringRoleQuery = fmt.Sprintf(
+	"SELECT COALESCE(rolpassword, ''), pg_has_role(oid, 'pg_monitor', 'MEMBER') FROM pg_authid WHERE rolname = %s",
+	QuoteLiteral(MonitoringRole))
+
+// ServiceMonitorGroupVersionKind is the kind of the Prometheus Operator
+// resource scraping the metrics of an instance
+var ServiceMonitorGroupVersionKind = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// IsMonitored returns true if a metrics exporter runs next to the given
+// instance
+func IsMonitored(ps *v1alpha1.Postgres) bool {
+	return ps.Spec.ForProvider.Monitoring != nil
+}
+
+// ValidateMonitoring checks that the given instance can be monitored
+func ValidateMonitoring(ps *v1alpha1.Postgres) error {
+	if IsMonitored(ps) && IsReplicated(ps) {
+		return errors.New(errMonitoringReplicated)
+	}
+	return nil
+}
+
+// ExporterImage returns the image of the metrics exporter of the given
+// instance
+func ExporterImage(ps *v1alpha1.Postgres) string {
+	if m := ps.Spec.ForProvider.Monitoring; m != nil && m.Image != nil {
+		return *m.Image
+	}
+	return DefaultExporterImage
+}
+
+// ExporterPort returns the port the metrics of the given instance are served
+// on
+func ExporterPort(ps *v1alpha1.Postgres) int {
+	if m := ps.Spec.ForProvider.Monitoring; m != nil && m.Port != nil {
+		return *m.Port
+	}
+	return DefaultExporterPort
+}
+
+// MonitoringSecretName returns the name of the Secret holding the password of
+// the monitoring role of the given instance
+func MonitoringSecretName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-monitoring"
+}
+
+// MakeMonitoringSecret creates the Secret holding the password of the
+// monitoring role of the given instance
+func MakeMonitoringSecret(ps *v1alpha1.Postgres, pw string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: objectMeta(ps, MonitoringSecretName(ps)),
+		Type:       v1.SecretTypeOpaque,
+		Data:       map[string][]byte{PasswordSecretKey: []byte(pw)},
+	}
+}
+
+// addExporter adds the metrics exporter of a monitored instance to the given
+// pod template. It connects to Postgres in the same pod as the monitoring
+// role.
+func addExporter(ps *v1alpha1.Postgres, tpl *v1.PodTemplateSpec) {
+	m := ps.Spec.ForProvider.Monitoring
+	if m == nil {
+		return
+	}
+	port := ExporterPort(ps)
+	c := v1.Container{
+		Name:  exporterContainerName,
+		Image: ExporterImage(ps),
+		Ports: []v1.ContainerPort{
+			{
+				Name:          metricsPortName,
+				ContainerPort: int32(port),
+				Protocol:      v1.ProtocolTCP,
+			},
+		},
+		Env: []v1.EnvVar{
+			envVarFromValue("DATA_SOURCE_URI", fmt.Sprintf("127.0.0.1:%d/%s?sslmode=disable",
+				DefaultPostgresPort, utils.StringValue(ps.Spec.ForProvider.Database))),
+			envVarFromValue("DATA_SOURCE_USER", MonitoringRole),
+			envVarFromSecret("DATA_SOURCE_PASS", MonitoringSecretName(ps), PasswordSecretKey),
+			envVarFromValue("PG_EXPORTER_WEB_LISTEN_ADDRESS", ":"+strconv.Itoa(port)),
+		},
+		ReadinessProbe: &v1.Probe{
+			Handler: v1.Handler{
+				HTTPGet: &v1.HTTPGetAction{Path: metricsPath, Port: intstr.FromString(metricsPortName)},
+			},
+			PeriodSeconds:  30,
+			TimeoutSeconds: 5,
+		},
+		ImagePullPolicy: v1.PullIfNotPresent,
+	}
+	if m.Resources != nil {
+		c.Resources = *m.Resources.DeepCopy()
+	}
+	tpl.Spec.Containers = append(tpl.Spec.Containers, c)
+}
+
+// addMetricsPort exposes the metrics of a monitored instance on the given
+// Service
+func addMetricsPort(ps *v1alpha1.Postgres, svc *v1.Service) {
+	if !IsMonitored(ps) {
+		return
+	}
+	svc.Spec.Ports = append(svc.Spec.Ports, v1.ServicePort{
+		Name:       metricsPortName,
+		Protocol:   v1.ProtocolTCP,
+		Port:       int32(ExporterPort(ps)),
+		TargetPort: intstr.FromString(metricsPortName),
+	})
+}
+
+// hasExporter checks that the observed pod template runs the containers of
+// the desired one. DeepDerivative ignores the exporter once monitoring is
+// turned off.
+func hasExporter(desired, observed v1.PodTemplateSpec) bool {
+	if len(desired.Spec.Containers) != len(observed.Spec.Containers) {
+		return false
+	}
+	for i := range desired.Spec.Containers {
+		if desired.Spec.Containers[i].Name != observed.Spec.Containers[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// MonitoringStatements returns the statements converging the monitoring role
+// described by out, the output of ObserveMonitoringRoleQuery, to the given
+// instance: it is created with the given password and the privileges of
+// pg_monitor while the instance is monitored, and dropped afterwards. It also
+// returns whether the role exists.
+func MonitoringStatements(ps *v1alpha1.Postgres, out, password string) ([]string, bool, error) {
+	rows := SplitRows(out)
+	role := QuoteIdentifier(MonitoringRole)
+	if len(rows) == 0 {
+		if !IsMonitored(ps) {
+			return nil, false, nil
+		}
+		return []string{
+			fmt.Sprintf("CREATE ROLE %s WITH LOGIN PASSWORD %s", role, QuoteLiteral(password)),
+			fmt.Sprintf("GRANT pg_monitor TO %s", role),
+		}, false, nil
+	}
+	row := rows[0]
+	if len(row) != 2 {
+		return nil, false, errors.Errorf(errUnexpectedMonitoringRole, strings.Join(row, "|"))
+	}
+	if !IsMonitored(ps) {
+		return []string{fmt.Sprintf("DROP ROLE %s", role)}, true, nil
+	}
+	var stmts []string
+	if !PasswordMatches(row[0], MonitoringRole, password) {
+		stmts = append(stmts, fmt.Sprintf("ALTER ROLE %s WITH LOGIN PASSWORD %s", role, QuoteLiteral(password)))
+	}
+	if row[1] != "t" {
+		stmts = append(stmts, fmt.Sprintf("GRANT pg_monitor TO %s", role))
+	}
+	return stmts, true, nil
+}
+
+// WantsServiceMonitor returns true if a ServiceMonitor is created for the
+// given instance
+func WantsServiceMonitor(ps *v1alpha1.Postgres) bool {
+	m := ps.Spec.ForProvider.Monitoring
+	return m != nil && utils.BoolValueFallback(m.ServiceMonitor, false)
+}
+
+// MakeServiceMonitor creates the ServiceMonitor scraping the metrics port of
+// the Service of the given instance. The Service is selected by its owner.
+func MakeServiceMonitor(ps *v1alpha1.Postgres) *unstructured.Unstructured {
+	om := objectMeta(ps, ps.Name)
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ServiceMonitorGroupVersionKind)
+	u.SetName(om.Name)
+	u.SetNamespace(om.Namespace)
+	u.SetAnnotations(om.Annotations)
+	labels := map[string]string{}
+	selector := map[string]interface{}{}
+	if m := ps.Spec.ForProvider.Monitoring; m != nil {
+		labels = mergeStringMap(labels, m.ServiceMonitorLabels)
+	}
+	for k, v := range om.Labels {
+		labels[k] = v
+		selector[k] = v
+	}
+	u.SetLabels(labels)
+	endpoint := map[string]interface{}{"port": metricsPortName, "path": metricsPath}
+	if m := ps.Spec.ForProvider.Monitoring; m != nil && utils.StringValue(m.Interval) != "" {
+		endpoint["interval"] = *m.Interval
+	}
+	u.Object["spec"] = map[string]interface{}{
+		"selector":  map[string]interface{}{"matchLabels": selector},
+		"endpoints": []interface{}{endpoint},
+	}
+	return u
+}
+
+// IsServiceMonitorUpToDate checks whether the observed ServiceMonitor still
+// matches the desired one
+func IsServiceMonitorUpToDate(desired, observed *unstructured.Unstructured) bool {
+	return equality.Semantic.DeepDerivative(desired.GetLabels(), observed.GetLabels()) &&
+		equality.Semantic.DeepEqual(desired.Object["spec"], observed.Object["spec"])
+}
+
+// DeletePostgresMonitoring deletes the ServiceMonitor and the monitoring
+// Secret of the instance. A missing ServiceMonitor CRD is ignored.
+func (c postgresClient) DeletePostgresMonitoring(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	sm := &unstructured.Unstructured{}
+	sm.SetGroupVersionKind(ServiceMonitorGroupVersionKind)
+	err := c.kube.Get(ctx, client.ObjectKey{Name: postgres.Name, Namespace: postgres.Namespace}, sm)
+	if err != nil && !IsMissing(err) {
+		return err
+	}
+	if err == nil && clients.IsOwnedBy(sm, postgres) {
+		if err := c.kube.Delete(ctx, sm); err != nil && !IsMissing(err) {
+			return err
+		}
+	}
+	s := v1.Secret{}
+	err = c.kube.Get(ctx, client.ObjectKey{Name: MonitoringSecretName(postgres), Namespace: postgres.Namespace}, &s)
+	if err != nil || !clients.IsOwnedBy(&s, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &s)
+}
+
+// IsMissing returns true if err reports that an object or its kind does not
+// exist, e.g. a ServiceMonitor in a cluster without the Prometheus Operator
+func IsMissing(err error) bool {
+	return kerrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"strings"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+)
+
+// Namespace returns the namespace in the target cluster the objects of the
+// given instance are created in
+func Namespace(ps *v1alpha1.Postgres) string {
+	if ps.Spec.ForProvider.Namespace == nil || strings.TrimSpace(*ps.Spec.ForProvider.Namespace) == "" {
+		return DefaultNamespace
+	}
+	return *ps.Spec.ForProvider.Namespace
+}
+
+// objectNames returns the names of the objects the provider creates for the
+// given instance in its namespace
+func objectNames(ps *v1alpha1.Postgres) []string {
+	return []string{
+		ps.Name,
+		ConfigMapName(ps),
+		PasswordSecretName(ps),
+		GeneratedTLSSecretName(ps),
+		ReadOnlyServiceName(ps),
+		ReplicationConfigMapName(ps),
+		RestoreJobName(ps),
+		UpgradeJobName(ps),
+		PreUpgradeSnapshotName(ps),
+	}
+}
+
+// Conflicts returns true if the given instances are placed in the same
+// namespace and the provider would create objects with the same name for
+// both, e.g. the read-only Service of db and the Service of db-ro
+func Conflicts(a, b *v1alpha1.Postgres) bool {
+	if a.Name == b.Name || Namespace(a) != Namespace(b) {
+		return false
+	}
+	names := map[string]bool{}
+	for _, n := range objectNames(a) {
+		names[n] = true
+	}
+	for _, n := range objectNames(b) {
+		if names[n] {
+			return true
+		}
+	}
+	return false
+}
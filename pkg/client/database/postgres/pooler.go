@@ -0,0 +1,378 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	// DefaultPgBouncerImage is the image of PgBouncer if none is set
+	DefaultPgBouncerImage = "edoburu/pgbouncer:1.15.0"
+	// DefaultPoolSize is the number of server connections per user and
+	// database if none is set
+	DefaultPoolSize = 20
+	// DefaultMaxClientConnections is the number of client connections a
+	// pooler accepts if none is set
+	DefaultMaxClientConnections = 1000
+	// PgBouncerPort is the port PgBouncer listens on in its pods
+	PgBouncerPort = 6432
+	// PoolerConfigKey is the key of the pgbouncer.ini in the Secret of a
+	// pooler
+	PoolerConfigKey = "pgbouncer.ini"
+	// PoolerUserlistKey is the key of the auth_file in the Secret of a pooler
+	PoolerUserlistKey = "userlist.txt"
+	// PgBouncerContainerName is the name of the PgBouncer container in the
+	// pods of a pooler
+	PgBouncerContainerName = "pgbouncer"
+	// LabelPooler selects the pods of a pooler
+	LabelPooler = "pooler"
+
+	// UserlistQuery lists the login roles of an instance with their password
+	// hash, one row each
+	UserlistQuery = "SELECT rolname, rolpassword FROM pg_authid WHERE rolcanlogin AND rolpassword IS NOT NULL ORDER BY rolname"
+
+	pgbouncerPortName   = "pgbouncer"
+	poolerVolumeName    = "pgbouncer-config"
+	poolerMountPath     = "/etc/pgbouncer"
+	poolerTLSVolumeName = "tls"
+	poolerTLSMountPath  = "/etc/pgbouncer-tls"
+
+	errUnexpectedUserlistRow = "unexpected row describing postgres role: %q"
+)
+
+// poolerTemplate is the pgbouncer.ini of a pooler. Every database of the
+// instance is served, clients authenticate against the userlist.
+const poolerTemplate = `[databases]
+* = host=%s port=%d
+
+[pgbouncer]
+listen_addr = *
+listen_port = %d
+unix_socket_dir =
+auth_type = md5
+auth_file = %s
+pool_mode = %s
+default_pool_size = %d
+min_pool_size = %d
+reserve_pool_size = %d
+max_client_conn = %d
+ignore_startup_parameters = extra_float_digits
+`
+
+// poolerTLSTemplate makes a pooler serve TLS connections with the
+// certificate of its instance. Clients connect with the sslmode the instance
+// publishes, which verifies the CA but not the hostname.
+const poolerTLSTemplate = `client_tls_sslmode = %s
+client_tls_cert_file = %s
+client_tls_key_file = %s
+server_tls_sslmode = require
+`
+
+// PoolMode returns the pool mode of the given pooler
+func PoolMode(cr *v1alpha1.PostgresPooler) v1alpha1.PostgresPoolMode {
+	if cr.Spec.ForProvider.PoolMode == nil {
+		return v1alpha1.PoolModeTransaction
+	}
+	return *cr.Spec.ForProvider.PoolMode
+}
+
+// PoolerPort returns the port the Service of the given pooler listens on
+func PoolerPort(cr *v1alpha1.PostgresPooler) int {
+	if cr.Spec.ForProvider.Port == nil {
+		return DefaultPostgresPort
+	}
+	return *cr.Spec.ForProvider.Port
+}
+
+// PoolerImage returns the PgBouncer image of the given pooler
+func PoolerImage(cr *v1alpha1.PostgresPooler) string {
+	return utils.StringValueFallback(cr.Spec.ForProvider.Image, DefaultPgBouncerImage)
+}
+
+// PoolerSecretName returns the name of the Secret holding the pgbouncer.ini
+// and the userlist of the given pooler
+func PoolerSecretName(cr *v1alpha1.PostgresPooler) string {
+	return cr.Name + "-pgbouncer"
+}
+
+// poolerMeta returns the metadata of the object with the given name the
+// provider creates for the given pooler, in the namespace recorded in its
+// status and labelled with its owner
+func poolerMeta(cr *v1alpha1.PostgresPooler, name string) metav1.ObjectMeta {
+	om := metav1.ObjectMeta{Name: name, Namespace: cr.Status.AtProvider.Namespace}
+	clients.SetOwner(&om, cr, v1alpha1.PostgresPoolerGroupVersionKind)
+	return om
+}
+
+// upstreamPort returns the port a pooler connects to the given instance on.
+// The DNS name of a Headless Service resolves to the pods, which listen on
+// the default port.
+func upstreamPort(ps *v1alpha1.Postgres) int {
+	if ServiceType(ps) == v1alpha1.ServiceTypeHeadless {
+		return DefaultPostgresPort
+	}
+	return Port(ps)
+}
+
+// MakeUserlist renders the auth_file of a pooler from out, the output of
+// UserlistQuery, and returns the number of roles in it. Role names may
+// contain the field separator, password hashes may not.
+func MakeUserlist(out string) (string, int, error) {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	b := &strings.Builder{}
+	rows := SplitRows(out)
+	for _, row := range rows {
+		if len(row) < 2 {
+			return "", 0, errors.Errorf(errUnexpectedUserlistRow, strings.Join(row, "|"))
+		}
+		name := strings.Join(row[:len(row)-1], "|")
+		fmt.Fprintf(b, "%s %s\n", quote(name), quote(row[len(row)-1]))
+	}
+	return b.String(), len(rows), nil
+}
+
+// MakePoolerConfig renders the pgbouncer.ini of the given pooler connecting
+// to the primary of the given instance. If the instance serves TLS, so does
+// the pooler, and client connections are encrypted like those to the
+// instance.
+func MakePoolerConfig(cr *v1alpha1.PostgresPooler, ps *v1alpha1.Postgres) string {
+	p := cr.Spec.ForProvider
+	conf := &strings.Builder{}
+	fmt.Fprintf(conf, poolerTemplate,
+		PrimaryHost(ps), upstreamPort(ps),
+		PgBouncerPort,
+		path.Join(poolerMountPath, PoolerUserlistKey),
+		PoolMode(cr),
+		utils.IntValueFallback(p.DefaultPoolSize, DefaultPoolSize),
+		utils.IntValueFallback(p.MinPoolSize, 0),
+		utils.IntValueFallback(p.ReservePoolSize, 0),
+		utils.IntValueFallback(p.MaxClientConnections, DefaultMaxClientConnections))
+	if ps.Spec.ForProvider.TLS != nil {
+		mode := "prefer"
+		if IsTLSEnforced(ps) {
+			mode = sslModeRequire
+		}
+		fmt.Fprintf(conf, poolerTLSTemplate, mode,
+			path.Join(poolerTLSMountPath, v1.TLSCertKey),
+			path.Join(poolerTLSMountPath, v1.TLSPrivateKeyKey))
+	}
+	return conf.String()
+}
+
+// MakePoolerSecret creates the Secret holding the pgbouncer.ini and the
+// given userlist of the given pooler
+func MakePoolerSecret(cr *v1alpha1.PostgresPooler, ps *v1alpha1.Postgres, userlist string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: poolerMeta(cr, PoolerSecretName(cr)),
+		Type:       v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			PoolerConfigKey:   []byte(MakePoolerConfig(cr, ps)),
+			PoolerUserlistKey: []byte(userlist),
+		},
+	}
+}
+
+// IsPoolerSecretUpToDate checks whether the observed Secret of a pooler
+// still holds the desired configuration and userlist
+func IsPoolerSecretUpToDate(desired, observed *v1.Secret) bool {
+	return equality.Semantic.DeepEqual(desired.Data, observed.Data)
+}
+
+// PoolerChecksum returns the checksum of the pgbouncer.ini and the userlist
+// in the given Secret
+func PoolerChecksum(s *v1.Secret) string {
+	sum := sha256.Sum256(append(append([]byte{}, s.Data[PoolerConfigKey]...), s.Data[PoolerUserlistKey]...))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReloadPoolerCommand makes PgBouncer, which runs as the first process of
+// its container, reread its files once the pgbouncer.ini and userlist
+// mounted into the pod have the given checksum. Like ReloadConfigCommand it
+// only prints "stale" if the pod does not see the files yet, as updates of a
+// Secret reach the pods with a delay.
+func ReloadPoolerCommand(checksum string) string {
+	return fmt.Sprintf(`if [ "$(cat %s %s 2>/dev/null | sha256sum | cut -d ' ' -f 1)" != %s ]; then echo %s; exit 0; fi; kill -HUP 1`,
+		path.Join(poolerMountPath, PoolerConfigKey), path.Join(poolerMountPath, PoolerUserlistKey), quoteShell(checksum), configStale)
+}
+
+// MakePoolerDeployment creates the Deployment running PgBouncer with the
+// configuration of the given Secret for the given pooler of the given
+// instance. The Secret is mounted as a whole, so that changes reach the
+// running pods, which reload them without dropping client connections.
+func MakePoolerDeployment(cr *v1alpha1.PostgresPooler, ps *v1alpha1.Postgres, s *v1.Secret) *appsv1.Deployment {
+	labels := map[string]string{LabelPooler: cr.Name}
+	uid := int64(PostgresUID)
+	probe := func(delay int32) *v1.Probe {
+		return &v1.Probe{
+			Handler: v1.Handler{
+				TCPSocket: &v1.TCPSocketAction{Port: intstr.FromString(pgbouncerPortName)},
+			},
+			InitialDelaySeconds: delay,
+			PeriodSeconds:       10,
+		}
+	}
+	c := v1.Container{
+		Name:    PgBouncerContainerName,
+		Image:   PoolerImage(cr),
+		Command: []string{"pgbouncer", path.Join(poolerMountPath, PoolerConfigKey)},
+		Ports: []v1.ContainerPort{
+			{
+				Name:          pgbouncerPortName,
+				ContainerPort: PgBouncerPort,
+				Protocol:      v1.ProtocolTCP,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      poolerVolumeName,
+				MountPath: poolerMountPath,
+				ReadOnly:  true,
+			},
+		},
+		LivenessProbe:   probe(30),
+		ReadinessProbe:  probe(5),
+		ImagePullPolicy: v1.PullIfNotPresent,
+	}
+	if r := cr.Spec.ForProvider.Resources; r != nil {
+		c.Resources = *r.DeepCopy()
+	}
+	depl := &appsv1.Deployment{
+		ObjectMeta: poolerMeta(cr, cr.Name),
+		Spec: appsv1.DeploymentSpec{
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+			},
+			Replicas: utils.Int32(int32(utils.IntValueFallback(cr.Spec.ForProvider.Replicas, 1))),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{},
+				},
+				Spec: v1.PodSpec{
+					// PgBouncer refuses to run as root and only reads its
+					// files, so it runs as an arbitrary user in any profile
+					SecurityContext: &v1.PodSecurityContext{
+						RunAsNonRoot: utils.Bool(true),
+						RunAsUser:    &uid,
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: poolerVolumeName,
+							VolumeSource: v1.VolumeSource{
+								Secret: &v1.SecretVolumeSource{SecretName: s.Name},
+							},
+						},
+					},
+					Containers: []v1.Container{c},
+				},
+			},
+		},
+	}
+	addPoolerTLS(ps, &depl.Spec.Template)
+	restrictPodSecurity(cr.Status.AtProvider.PodSecurityProfile, &depl.Spec.Template, true)
+	return depl
+}
+
+// addPoolerTLS mounts the certificate of an instance with TLS into the given
+// pod template of its pooler. PgBouncer, unlike Postgres, reads a key other
+// users can access, so the Secret is mounted as is. The expiry of the
+// certificate is recorded on the pods, so that a renewed certificate rolls
+// them.
+func addPoolerTLS(ps *v1alpha1.Postgres, tpl *v1.PodTemplateSpec) {
+	if ps.Spec.ForProvider.TLS == nil {
+		return
+	}
+	tpl.Spec.Volumes = append(tpl.Spec.Volumes, v1.Volume{
+		Name: poolerTLSVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: TLSSecretName(ps)},
+		},
+	})
+	tpl.Spec.Containers[0].VolumeMounts = append(tpl.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      poolerTLSVolumeName,
+		MountPath: poolerTLSMountPath,
+		ReadOnly:  true,
+	})
+	if t := ps.Status.AtProvider.CertificateNotAfter; t != nil {
+		tpl.Annotations[AnnotationCertificate] = t.UTC().Format(time.RFC3339)
+	}
+}
+
+// MakePoolerService creates the ClusterIP Service clients connect to the
+// given pooler through
+func MakePoolerService(cr *v1alpha1.PostgresPooler) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: poolerMeta(cr, cr.Name),
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeClusterIP,
+			Ports: []v1.ServicePort{
+				{
+					Name:       "postgresql",
+					Protocol:   v1.ProtocolTCP,
+					Port:       int32(PoolerPort(cr)),
+					TargetPort: intstr.FromString(pgbouncerPortName),
+				},
+			},
+			Selector: map[string]string{LabelPooler: cr.Name},
+		},
+	}
+}
+
+// DeletePostgresPooler deletes the Deployment, the Service and the Secret of
+// the given pooler which it owns
+func (c postgresClient) DeletePostgresPooler(ctx context.Context, cr *v1alpha1.PostgresPooler) error {
+	for _, obj := range []interface {
+		runtime.Object
+		metav1.Object
+	}{&appsv1.Deployment{}, &v1.Service{}, &v1.Secret{}} {
+		name := cr.Name
+		if _, ok := obj.(*v1.Secret); ok {
+			name = PoolerSecretName(cr)
+		}
+		err := c.kube.Get(ctx, client.ObjectKey{Name: name, Namespace: cr.Status.AtProvider.Namespace}, obj)
+		if err != nil || !clients.IsOwnedBy(obj, cr) {
+			continue
+		}
+		if err := c.kube.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
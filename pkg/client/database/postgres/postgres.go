@@ -17,31 +17,64 @@ limitations under the License.
 package postgres
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
 	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
 )
 
 const (
 	errGetPasswordSecretFailed = "cannot get password secret"
+	errUnsupportedObject       = "cannot update unsupported object type %T"
+	errNoReadyPod              = "no ready pod of postgres instance %s"
+	envPostgresPassword        = "POSTGRES_PASSWORD"
+	// PasswordSecretKey is the key of the master password in the password
+	// Secret of an instance
+	PasswordSecretKey = "password"
 	// DefaultPostgresPort is the default port for postgres
 	DefaultPostgresPort = 5432
-	// ImageTagPostgres is the tag for the default postgres image used
-	ImageTagPostgres = "postgres:13.0"
+	// DefaultNamespace is the namespace instances are placed in if none is set
+	DefaultNamespace = "default"
+	// DefaultMasterUsername is the name of the master user if none is set
+	DefaultMasterUsername = "postgres"
+	// DefaultPostgresVersion is the postgres version used if none is specified
+	DefaultPostgresVersion = "13.0"
+	// ImagePostgres is the repository of the default postgres image used
+	ImagePostgres = "postgres"
+	// ImagePostgresUpgrade is the repository of the image used to run pg_upgrade
+	ImagePostgresUpgrade = "tianon/postgres-upgrade"
+	// AnnotationVersion records the postgres version a Deployment was rendered for
+	AnnotationVersion = "in-cluster.crossplane.io/postgres-version"
+	// AnnotationClass records the PostgresClass whose values were copied into
+	// the spec of an instance
+	AnnotationClass = "in-cluster.crossplane.io/postgres-class"
+
+	dataMountPath       = "/var/lib/pgsql/data"
+	preUpgradeMountPath = "/var/lib/pgsql/pre-upgrade"
 )
 
 // Client is the interface for the postgres client
@@ -49,13 +82,31 @@ type Client interface {
 	CreateOrUpdate(ctx context.Context, obj runtime.Object) (controllerutil.OperationResult, error)
 	ParseInputSecret(ctx context.Context, postgres v1alpha1.Postgres) (string, error)
 	DeletePostgresPVC(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DataClaims(ctx context.Context, postgres *v1alpha1.Postgres) ([]v1.PersistentVolumeClaim, error)
+	RetainPostgresPVC(ctx context.Context, postgres *v1alpha1.Postgres) error
+	SnapshotPostgresPVC(ctx context.Context, postgres *v1alpha1.Postgres) ([]string, bool, error)
 	DeletePostgresDeployment(ctx context.Context, postgres *v1alpha1.Postgres) error
 	DeletePostgresService(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresUpgradeJob(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresRestoreJob(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresPasswordSecret(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresStatefulSet(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresReplication(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresConfig(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresTLSSecret(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresMonitoring(ctx context.Context, postgres *v1alpha1.Postgres) error
+	DeletePostgresPooler(ctx context.Context, cr *v1alpha1.PostgresPooler) error
+	SetPodRole(ctx context.Context, pod *v1.Pod, role string) error
+	DeletePod(ctx context.Context, pod *v1.Pod) error
+	ExecInPod(ctx context.Context, pod *v1.Pod, container, cmd string) (string, error)
+	ExecSQL(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error)
 	GeneratePassword() (string, error)
 }
 
 type postgresClient struct {
 	kube client.Client
+	cs   kubernetes.Interface
+	rc   *rest.Config
 }
 
 func (c postgresClient) GeneratePassword() (string, error) {
@@ -67,12 +118,32 @@ func (c postgresClient) GeneratePassword() (string, error) {
 }
 
 func (c postgresClient) DeletePostgresPVC(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	if IsReplicated(postgres) {
+		// the StatefulSet keeps the claims of scaled down replicas, so all of
+		// them are selected by label
+		err := c.kube.DeleteAllOf(ctx, &v1.PersistentVolumeClaim{}, client.InNamespace(postgres.Namespace),
+			client.MatchingLabels{LabelStatefulSet: postgres.Name})
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{postgres.Name, PreUpgradeSnapshotName(postgres)} {
+		if err := c.deleteOwnedPVC(ctx, postgres, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOwnedPVC deletes the PVC with the given name if the given instance
+// owns it
+func (c postgresClient) deleteOwnedPVC(ctx context.Context, postgres *v1alpha1.Postgres, name string) error {
 	pvc := v1.PersistentVolumeClaim{}
 	err := c.kube.Get(ctx, client.ObjectKey{
 		Namespace: postgres.Namespace,
-		Name:      postgres.Name,
+		Name:      name,
 	}, &pvc)
-	if err != nil {
+	if err != nil || !clients.IsOwnedBy(&pvc, postgres) {
 		return nil
 	}
 	return c.kube.Delete(ctx, &pvc)
@@ -87,6 +158,9 @@ func (c postgresClient) DeletePostgresDeployment(ctx context.Context, postgres *
 	if err != nil {
 		return nil
 	}
+	if !clients.IsOwnedBy(&dpl, postgres) {
+		return nil
+	}
 	return c.kube.Delete(ctx, &dpl)
 }
 
@@ -99,21 +173,299 @@ func (c postgresClient) DeletePostgresService(ctx context.Context, postgres *v1a
 	if err != nil {
 		return nil
 	}
+	if !clients.IsOwnedBy(&svc, postgres) {
+		return nil
+	}
 	return c.kube.Delete(ctx, &svc)
 }
 
+func (c postgresClient) DeletePostgresUpgradeJob(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	job := batchv1.Job{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      UpgradeJobName(postgres),
+		Namespace: postgres.Namespace,
+	}, &job)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&job, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
+
+func (c postgresClient) DeletePostgresRestoreJob(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	job := batchv1.Job{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      RestoreJobName(postgres),
+		Namespace: postgres.Namespace,
+	}, &job)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&job, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
+
+func (c postgresClient) DeletePostgresPasswordSecret(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	s := v1.Secret{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      PasswordSecretName(postgres),
+		Namespace: postgres.Namespace,
+	}, &s)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&s, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &s)
+}
+
+func (c postgresClient) DeletePostgresStatefulSet(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	sts := appsv1.StatefulSet{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      postgres.Name,
+		Namespace: postgres.Namespace,
+	}, &sts)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&sts, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &sts)
+}
+
+func (c postgresClient) DeletePostgresReplication(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	svc := v1.Service{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      ReadOnlyServiceName(postgres),
+		Namespace: postgres.Namespace,
+	}, &svc)
+	if err == nil && clients.IsOwnedBy(&svc, postgres) {
+		if err := c.kube.Delete(ctx, &svc); err != nil {
+			return err
+		}
+	}
+	cm := v1.ConfigMap{}
+	err = c.kube.Get(ctx, client.ObjectKey{
+		Name:      ReplicationConfigMapName(postgres),
+		Namespace: postgres.Namespace,
+	}, &cm)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&cm, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &cm)
+}
+
+func (c postgresClient) DeletePostgresConfig(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	cm := v1.ConfigMap{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      ConfigMapName(postgres),
+		Namespace: postgres.Namespace,
+	}, &cm)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&cm, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &cm)
+}
+
+// DeletePostgresTLSSecret deletes the Secret holding the generated
+// certificate of the instance. A Secret referenced by the instance is kept.
+func (c postgresClient) DeletePostgresTLSSecret(ctx context.Context, postgres *v1alpha1.Postgres) error {
+	if tls := postgres.Spec.ForProvider.TLS; tls != nil && utils.StringValue(tls.SecretName) == GeneratedTLSSecretName(postgres) {
+		return nil
+	}
+	s := v1.Secret{}
+	err := c.kube.Get(ctx, client.ObjectKey{
+		Name:      GeneratedTLSSecretName(postgres),
+		Namespace: postgres.Namespace,
+	}, &s)
+	if err != nil {
+		return nil
+	}
+	if !clients.IsOwnedBy(&s, postgres) {
+		return nil
+	}
+	return c.kube.Delete(ctx, &s)
+}
+
+// SetPodRole labels the given pod of a replicated instance with its role
+func (c postgresClient) SetPodRole(ctx context.Context, pod *v1.Pod, role string) error {
+	labelled := pod.DeepCopy()
+	labelled.Labels = mergeStringMap(labelled.Labels, map[string]string{LabelRole: role})
+	return c.kube.Patch(ctx, labelled, client.MergeFrom(pod))
+}
+
+// DeletePod deletes the given pod, e.g. a failed primary which has to rejoin
+// a replicated instance as standby
+func (c postgresClient) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	return client.IgnoreNotFound(c.kube.Delete(ctx, pod))
+}
+
+// ExecInPod runs the given shell command in a container of the given pod and
+// returns its output
+func (c postgresClient) ExecInPod(_ context.Context, pod *v1.Pod, container, cmd string) (string, error) {
+	return utils.ExecInContainer(c.rc, c.cs, pod, container, cmd)
+}
+
+// ExecSQL runs the given SQL statements as the master user in the given
+// database of the instance and returns the output
+func (c postgresClient) ExecSQL(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+	pod, err := c.primaryPod(ctx, ps)
+	if err != nil {
+		return "", err
+	}
+	return c.ExecInPod(ctx, pod, ps.Name, PsqlCommand(database, statements...))
+}
+
+// primaryPod returns a ready pod accepting writes for the given instance
+func (c postgresClient) primaryPod(ctx context.Context, ps *v1alpha1.Postgres) (*v1.Pod, error) {
+	selector := client.MatchingLabels{"deployment": ps.Name}
+	if IsReplicated(ps) {
+		selector = client.MatchingLabels{LabelStatefulSet: ps.Name, LabelRole: RolePrimary}
+	}
+	pods := &v1.PodList{}
+	if err := c.kube.List(ctx, pods, client.InNamespace(ps.Namespace), selector); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if pods.Items[i].DeletionTimestamp == nil && IsPodReady(&pods.Items[i]) {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, errors.Errorf(errNoReadyPod, ps.Name)
+}
+
+// deleteIfHeadlessChanged deletes the existing Service with the name of the
+// given one if only one of them is headless. The cluster IP of a Service is
+// immutable, so it has to be recreated.
+func (c postgresClient) deleteIfHeadlessChanged(ctx context.Context, svc *v1.Service) error {
+	cur := &v1.Service{}
+	err := c.kube.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, cur)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil || IsHeadless(cur) == IsHeadless(svc) {
+		return err
+	}
+	if err := clients.CheckAdoption(cur, svc); err != nil {
+		return err
+	}
+	return client.IgnoreNotFound(c.kube.Delete(ctx, cur))
+}
+
+// GetInstance fetches the Postgres instance with the given name and places it
+// in the namespace its objects are created in
+func GetInstance(ctx context.Context, kube client.Reader, name string) (*v1alpha1.Postgres, error) {
+	ps := &v1alpha1.Postgres{}
+	if err := kube.Get(ctx, types.NamespacedName{Name: name}, ps); err != nil {
+		return nil, err
+	}
+	ps.Namespace = Namespace(ps)
+	return ps, nil
+}
+
 // NewRoleClient creates the postgres client with interface
-func NewRoleClient(kube client.Client) Client {
-	return postgresClient{kube: kube}
+func NewRoleClient(kube client.Client, cs kubernetes.Interface, rc *rest.Config) Client {
+	return postgresClient{kube: kube, cs: cs, rc: rc}
 }
 
-// CreateOrUpdate parses the secret to get the database password
+// CreateOrUpdate creates the given object or, if it already exists, converges
+// the mutable parts of the existing object to the given desired state. An
+// existing object which is not owned by the managed resource the desired
+// object is created for is only taken over if it is annotated for adoption.
 func (c postgresClient) CreateOrUpdate(ctx context.Context, obj runtime.Object) (controllerutil.OperationResult, error) {
+	if svc, ok := obj.(*v1.Service); ok {
+		if err := c.deleteIfHeadlessChanged(ctx, svc); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+	}
+	desired := obj.DeepCopyObject()
 	return controllerutil.CreateOrUpdate(ctx, c.kube, obj, func() error {
-		return nil
+		return mergeDesiredState(desired, obj)
 	})
 }
 
+// mergeDesiredState copies the owner and the fields managed by the provider
+// from desired into current, leaving fields defaulted or allocated by the API
+// server alone.
+func mergeDesiredState(desired, current runtime.Object) error {
+	d, err := meta.Accessor(desired)
+	if err != nil {
+		return err
+	}
+	cm, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+	if err := clients.CheckAdoption(cm, d); err != nil {
+		return err
+	}
+	clients.CopyOwner(d, cm)
+	switch cur := current.(type) {
+	case *appsv1.Deployment:
+		d := desired.(*appsv1.Deployment)
+		cur.Annotations = mergeStringMap(cur.Annotations, d.Annotations)
+		cur.Spec.Replicas = d.Spec.Replicas
+		cur.Spec.Strategy = d.Spec.Strategy
+		cur.Spec.Template = d.Spec.Template
+	case *appsv1.StatefulSet:
+		d := desired.(*appsv1.StatefulSet)
+		// the volume claim templates are immutable
+		cur.Annotations = mergeStringMap(cur.Annotations, d.Annotations)
+		cur.Spec.Replicas = d.Spec.Replicas
+		cur.Spec.Template = d.Spec.Template
+	case *v1.ConfigMap:
+		d := desired.(*v1.ConfigMap)
+		cur.Data = d.Data
+	case *v1.Secret:
+		d := desired.(*v1.Secret)
+		cur.Data = d.Data
+	case *v1.Service:
+		d := desired.(*v1.Service)
+		// the cluster IP is allocated by the API server, switching from or to
+		// a headless Service replaces it in CreateOrUpdate
+		cur.Annotations = mergeServiceAnnotations(cur.Annotations, d.Annotations)
+		cur.Spec.Type = d.Spec.Type
+		cur.Spec.Ports = mergeServicePorts(cur.Spec.Ports, d.Spec.Ports, d.Spec.Type)
+		cur.Spec.Selector = d.Spec.Selector
+		cur.Spec.LoadBalancerSourceRanges = d.Spec.LoadBalancerSourceRanges
+	case *v1.PersistentVolumeClaim:
+		d := desired.(*v1.PersistentVolumeClaim)
+		// everything except the requested resources is immutable once bound
+		cur.Spec.Resources.Requests = d.Spec.Resources.Requests
+		// a retained claim is attached again
+		delete(cur.Labels, LabelRetainedFrom)
+		delete(cur.Annotations, clients.AnnotationAdopt)
+	case *unstructured.Unstructured:
+		d := desired.(*unstructured.Unstructured)
+		cur.SetLabels(mergeStringMap(cur.GetLabels(), d.GetLabels()))
+		cur.Object["spec"] = d.Object["spec"]
+	case *batchv1.Job:
+		// the pod template of a Job is immutable
+	case *batchv1beta1.CronJob:
+		d := desired.(*batchv1beta1.CronJob)
+		cur.Labels = mergeStringMap(cur.Labels, d.Labels)
+		cur.Spec.Schedule = d.Spec.Schedule
+		cur.Spec.Suspend = d.Spec.Suspend
+		cur.Spec.ConcurrencyPolicy = d.Spec.ConcurrencyPolicy
+		cur.Spec.JobTemplate = d.Spec.JobTemplate
+	default:
+		return errors.Errorf(errUnsupportedObject, current)
+	}
+	return nil
+}
+
 // ParseInputSecret parses the secret to get the database password
 func (c postgresClient) ParseInputSecret(ctx context.Context, postgres v1alpha1.Postgres) (string, error) {
 	if postgres.Spec.ForProvider.MasterPasswordSecretRef == nil {
@@ -137,11 +489,8 @@ func MakePVCPostgres(postgres *v1alpha1.Postgres) (*v1.PersistentVolumeClaim, er
 	if err != nil {
 		return nil, err
 	}
-	return &v1.PersistentVolumeClaim{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      postgres.Name,
-			Namespace: postgres.Namespace,
-		},
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: objectMeta(postgres, postgres.Name),
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "PersistentVolumeClaim",
 			APIVersion: "v1",
@@ -156,16 +505,17 @@ func MakePVCPostgres(postgres *v1alpha1.Postgres) (*v1.PersistentVolumeClaim, er
 				},
 			},
 		},
-	}, nil
+	}
+	if s := postgres.Spec.ForProvider.Source; s != nil && s.Snapshot != nil {
+		pvc.Spec.DataSource = SnapshotDataSource(s.Snapshot.Name)
+	}
+	return pvc, nil
 }
 
 // MakePostgresDeployment creates has the Deployment
-func MakePostgresDeployment(ps *v1alpha1.Postgres, pw string) *appsv1.Deployment {
+func MakePostgresDeployment(ps *v1alpha1.Postgres) *appsv1.Deployment {
 	depl := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      ps.Name,
-			Namespace: ps.Namespace,
-		},
+		ObjectMeta: objectMeta(ps, ps.Name),
 		Spec: appsv1.DeploymentSpec{
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RecreateDeploymentStrategyType,
@@ -187,26 +537,52 @@ func MakePostgresDeployment(ps *v1alpha1.Postgres, pw string) *appsv1.Deployment
 								},
 							},
 						},
+						configVolume(ps),
 					},
-					Containers: MakeDefaultPostgresPodContainers(ps, pw),
+					Containers: MakeDefaultPostgresPodContainers(ps),
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
 						"deployment": ps.Name,
 					},
+					Annotations: map[string]string{
+						AnnotationRestartParameters: RestartParameters(ps),
+					},
 				},
 			},
 		},
 	}
+	depl.Annotations[AnnotationVersion] = Version(ps)
+	addExporter(ps, &depl.Spec.Template)
+	addScheduling(ps, &depl.Spec.Template)
+	addTLS(ps, &depl.Spec.Template)
+	restrictPodSecurity(ps.Status.AtProvider.PodSecurityProfile, &depl.Spec.Template, true)
 	return depl
 }
 
-// MakeDefaultPostgresPodContainers creates the container for the Deployment
-func MakeDefaultPostgresPodContainers(ps *v1alpha1.Postgres, pw string) []v1.Container {
+// configVolume returns the volume of the ConfigMap holding postgresql.conf.
+// It is not mounted with a subPath, so that updates of the ConfigMap reach
+// running pods.
+func configVolume(ps *v1alpha1.Postgres) v1.Volume {
+	return v1.Volume{
+		Name: configVolumeName,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: ConfigMapName(ps)},
+			},
+		},
+	}
+}
+
+// MakeDefaultPostgresPodContainers creates the container for the Deployment.
+// The master password is taken from the password Secret of the instance, the
+// configuration from its config ConfigMap.
+func MakeDefaultPostgresPodContainers(ps *v1alpha1.Postgres) []v1.Container {
 	return []v1.Container{
 		{
 			Name:  ps.Name,
-			Image: ImageTagPostgres,
+			Image: Image(ps),
+			Args:  []string{"postgres", "-c", "config_file=" + configFilePath},
 			Ports: []v1.ContainerPort{
 				{
 					ContainerPort: DefaultPostgresPort,
@@ -215,23 +591,20 @@ func MakeDefaultPostgresPodContainers(ps *v1alpha1.Postgres, pw string) []v1.Con
 			},
 			Env: []v1.EnvVar{
 				envVarFromValue("POSTGRES_USER", utils.StringValue(ps.Spec.ForProvider.MasterUsername)),
-				envVarFromValue("POSTGRES_PASSWORD", pw),
+				envVarFromSecret(envPostgresPassword, PasswordSecretName(ps), PasswordSecretKey),
 				envVarFromValue("POSTGRES_DB", utils.StringValue(ps.Spec.ForProvider.Database)),
+				envVarFromValue("PGDATA", DataDirectory(Version(ps))),
 			},
-			Resources: v1.ResourceRequirements{
-				Limits: v1.ResourceList{
-					v1.ResourceCPU:    resource.MustParse("250m"),
-					v1.ResourceMemory: resource.MustParse("2Gi"),
-				},
-				Requests: v1.ResourceList{
-					v1.ResourceCPU:    resource.MustParse("50m"),
-					v1.ResourceMemory: resource.MustParse("512Mi"),
-				},
-			},
+			Resources: Resources(ps),
 			VolumeMounts: []v1.VolumeMount{
 				{
 					Name:      ps.Name,
-					MountPath: "/var/lib/pgsql/data",
+					MountPath: dataMountPath,
+				},
+				{
+					Name:      configVolumeName,
+					MountPath: configMountPath,
+					ReadOnly:  true,
 				},
 			},
 			LivenessProbe: &v1.Probe{
@@ -273,13 +646,40 @@ func envVarFromValue(envVarName, value string) v1.EnvVar {
 	}
 }
 
+// envVarFromSecret creates the environment variable for the pods from the key
+// of a Secret
+func envVarFromSecret(envVarName, secret, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: envVarName,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// PasswordSecretName returns the name of the Secret holding the master password
+// of the instance
+func PasswordSecretName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-password"
+}
+
+// MakePasswordSecret creates the Secret holding the master password the
+// instance runs with
+func MakePasswordSecret(ps *v1alpha1.Postgres, pw string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: objectMeta(ps, PasswordSecretName(ps)),
+		Type:       v1.SecretTypeOpaque,
+		Data:       map[string][]byte{PasswordSecretKey: []byte(pw)},
+	}
+}
+
 // MakeDefaultPostgresService is responsible for creating the Service for postgres
 func MakeDefaultPostgresService(ps *v1alpha1.Postgres) *v1.Service {
-	return &v1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      ps.Name,
-			Namespace: ps.Namespace,
-		},
+	svc := &v1.Service{
+		ObjectMeta: objectMeta(ps, ps.Name),
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
@@ -292,4 +692,291 @@ func MakeDefaultPostgresService(ps *v1alpha1.Postgres) *v1.Service {
 			Selector: map[string]string{"deployment": ps.Name},
 		},
 	}
+	addMetricsPort(ps, svc)
+	exposeService(ps, svc)
+	return svc
+}
+
+// objectMeta returns the metadata of the object with the given name the
+// provider creates for the given instance, labelled with its owner
+func objectMeta(ps *v1alpha1.Postgres, name string) metav1.ObjectMeta {
+	om := metav1.ObjectMeta{Name: name, Namespace: ps.Namespace}
+	clients.SetOwner(&om, ps, v1alpha1.PostgresGroupVersionKind)
+	return om
+}
+
+// mergeStringMap adds the entries of desired to current, overwriting existing
+// keys, and keeps the entries it does not know about
+func mergeStringMap(current, desired map[string]string) map[string]string {
+	if current == nil {
+		current = map[string]string{}
+	}
+	for k, v := range desired {
+		current[k] = v
+	}
+	return current
+}
+
+// PasswordFromPodTemplate returns the master password the given pod template
+// was rendered with, or an empty string if it cannot be found. Only instances
+// created before the password Secret was introduced carry their password in
+// the pod template.
+func PasswordFromPodTemplate(tpl v1.PodTemplateSpec) string {
+	for _, c := range tpl.Spec.Containers {
+		for _, e := range c.Env {
+			if e.Name == envPostgresPassword {
+				return e.Value
+			}
+		}
+	}
+	return ""
+}
+
+// IsDeploymentUpToDate checks whether the observed Deployment still matches the
+// desired one. Fields left empty in desired are defaulted by the API server and
+// are therefore not compared.
+func IsDeploymentUpToDate(desired, observed *appsv1.Deployment) bool {
+	return utils.Int32Value(desired.Spec.Replicas) == utils.Int32Value(observed.Spec.Replicas) &&
+		equality.Semantic.DeepDerivative(desired.Annotations, observed.Annotations) &&
+		desired.Spec.Strategy.Type == observed.Spec.Strategy.Type &&
+		equality.Semantic.DeepDerivative(desired.Spec.Template, observed.Spec.Template) &&
+		hasRestartParameters(desired.Spec.Template, observed.Spec.Template) &&
+		hasScheduling(desired.Spec.Template, observed.Spec.Template) &&
+		hasPodSecurity(desired.Spec.Template, observed.Spec.Template) &&
+		hasExporter(desired.Spec.Template, observed.Spec.Template)
+}
+
+// hasRestartParameters checks that the observed pod template was rendered for
+// the desired restart parameters. DeepDerivative ignores the annotation once
+// all of them are removed.
+func hasRestartParameters(desired, observed v1.PodTemplateSpec) bool {
+	return desired.Annotations[AnnotationRestartParameters] == observed.Annotations[AnnotationRestartParameters]
+}
+
+// IsPVCUpToDate checks whether the observed PersistentVolumeClaim still requests
+// the desired storage
+func IsPVCUpToDate(desired, observed *v1.PersistentVolumeClaim) bool {
+	return desired.Spec.Resources.Requests.Storage().Cmp(*observed.Spec.Resources.Requests.Storage()) == 0
+}
+
+// StorageResizeStatus returns the type of the resize condition currently set
+// on the given PersistentVolumeClaim, or an empty string if no expansion is in
+// progress
+func StorageResizeStatus(pvc *v1.PersistentVolumeClaim) string {
+	for _, c := range pvc.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		if c.Type == v1.PersistentVolumeClaimResizing || c.Type == v1.PersistentVolumeClaimFileSystemResizePending {
+			return string(c.Type)
+		}
+	}
+	return ""
+}
+
+// Version returns the postgres version requested for the given instance
+func Version(ps *v1alpha1.Postgres) string {
+	return utils.StringValueFallback(ps.Spec.ForProvider.Version, DefaultPostgresVersion)
+}
+
+// Port returns the port the Service of the given instance listens on
+func Port(ps *v1alpha1.Postgres) int {
+	if ps.Spec.ForProvider.Port == nil {
+		return DefaultPostgresPort
+	}
+	return *ps.Spec.ForProvider.Port
+}
+
+// Image returns the container image used for the given instance
+func Image(ps *v1alpha1.Postgres) string {
+	return utils.StringValueFallback(ps.Spec.ForProvider.Image, ImagePostgres+":"+Version(ps))
+}
+
+// Resources returns the compute resources of the Postgres container of the
+// given instance
+func Resources(ps *v1alpha1.Postgres) v1.ResourceRequirements {
+	if r := ps.Spec.ForProvider.Resources; r != nil {
+		return *r.DeepCopy()
+	}
+	return v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("250m"),
+			v1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("50m"),
+			v1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+	}
+}
+
+// ApplyClass copies the values of the given class into the unset parameters
+// of an instance. It returns whether any parameter was set.
+func ApplyClass(p *v1alpha1.PostgresParameters, class v1alpha1.PostgresClassSpec) bool {
+	updated := false
+	if p.DatabaseSize == "" && class.DatabaseSize != nil {
+		p.DatabaseSize = *class.DatabaseSize
+		updated = true
+	}
+	if p.StorageClass == nil && class.StorageClass != nil {
+		p.StorageClass = utils.String(*class.StorageClass)
+		updated = true
+	}
+	if p.Resources == nil && class.Resources != nil {
+		p.Resources = class.Resources.DeepCopy()
+		updated = true
+	}
+	for name, value := range class.Parameters {
+		if _, ok := p.Parameters[name]; ok {
+			continue
+		}
+		if p.Parameters == nil {
+			p.Parameters = map[string]string{}
+		}
+		p.Parameters[name] = value
+		updated = true
+	}
+	return updated
+}
+
+// ObservedVersion returns the postgres version the given Deployment or
+// StatefulSet was rendered for. Deployments created before versions were
+// selectable run the default version.
+func ObservedVersion(o metav1.Object) string {
+	if v, ok := o.GetAnnotations()[AnnotationVersion]; ok {
+		return v
+	}
+	return DefaultPostgresVersion
+}
+
+// MajorVersion returns the major part of a postgres version, which consists of
+// the first two components before postgres 10 and only the first one after.
+func MajorVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if major, err := strconv.Atoi(parts[0]); err == nil && major < 10 && len(parts) > 1 {
+		return parts[0] + "." + parts[1]
+	}
+	return parts[0]
+}
+
+// CompareMajorVersions returns -1, 0 or 1 if the major version of a is lower
+// than, equal to or greater than the major version of b
+func CompareMajorVersions(a, b string) int {
+	ma, _ := strconv.ParseFloat(MajorVersion(a), 64)
+	mb, _ := strconv.ParseFloat(MajorVersion(b), 64)
+	switch {
+	case ma < mb:
+		return -1
+	case ma > mb:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DataDirectory returns the PGDATA directory used for the given version. Every
+// major version gets its own directory on the PVC so that pg_upgrade can keep
+// the old cluster intact.
+func DataDirectory(version string) string {
+	return fmt.Sprintf("%s/%s/data", dataMountPath, MajorVersion(version))
+}
+
+// UpgradeJobName returns the name of the Job running pg_upgrade
+func UpgradeJobName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-upgrade"
+}
+
+// PreUpgradeSnapshotName returns the name of the PVC holding the copy of the
+// data taken before a major version upgrade
+func PreUpgradeSnapshotName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-pre-upgrade"
+}
+
+// MakePreUpgradeSnapshotPVC creates a PersistentVolumeClaim which is cloned from
+// the data PVC of the given instance
+func MakePreUpgradeSnapshotPVC(ps *v1alpha1.Postgres, pvc *v1.PersistentVolumeClaim) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: objectMeta(ps, PreUpgradeSnapshotName(ps)),
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			StorageClassName: pvc.Spec.StorageClassName,
+			Resources:        pvc.Spec.Resources,
+			DataSource: &v1.TypedLocalObjectReference{
+				Kind: "PersistentVolumeClaim",
+				Name: pvc.Name,
+			},
+		},
+	}
+}
+
+// MakePostgresUpgradeJob creates the Job which upgrades the data directory of
+// the given instance from one major version to another using pg_upgrade
+func MakePostgresUpgradeJob(ps *v1alpha1.Postgres, from, to string) *batchv1.Job {
+	user := utils.StringValue(ps.Spec.ForProvider.MasterUsername)
+	job := &batchv1.Job{
+		ObjectMeta: objectMeta(ps, UpgradeJobName(ps)),
+		Spec: batchv1.JobSpec{
+			BackoffLimit: utils.Int32(0),
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Volumes: []v1.Volume{
+						{
+							Name: ps.Name,
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: ps.Name,
+								},
+							},
+						},
+						{
+							// mounting the snapshot makes sure it is provisioned,
+							// and thereby cloned, before pg_upgrade starts
+							Name: PreUpgradeSnapshotName(ps),
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: PreUpgradeSnapshotName(ps),
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Containers: []v1.Container{
+						{
+							Name:  "pg-upgrade",
+							Image: fmt.Sprintf("%s:%s-to-%s", ImagePostgresUpgrade, MajorVersion(from), MajorVersion(to)),
+							// start from an empty new data directory so a failed
+							// attempt can simply be retried
+							Command: []string{"/bin/sh", "-c", `rm -rf "$PGDATANEW" && exec docker-upgrade pg_upgrade`},
+							Env: []v1.EnvVar{
+								envVarFromValue("PGDATAOLD", DataDirectory(from)),
+								envVarFromValue("PGDATANEW", DataDirectory(to)),
+								envVarFromValue("PGUSER", user),
+								envVarFromValue("POSTGRES_INITDB_ARGS", "--username="+user),
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      ps.Name,
+									MountPath: dataMountPath,
+								},
+								{
+									Name:      PreUpgradeSnapshotName(ps),
+									MountPath: preUpgradeMountPath,
+									ReadOnly:  true,
+								},
+							},
+							ImagePullPolicy: v1.PullIfNotPresent,
+						},
+					},
+				},
+			},
+		},
+	}
+	// the Job mounts the data of the instance and has to run where its pods
+	// may run
+	addNodePlacement(ps, &job.Spec.Template.Spec)
+	// pg_upgrade writes its logs into the working directory of the image
+	restrictPodSecurity(ps.Status.AtProvider.PodSecurityProfile, &job.Spec.Template, false)
+	return job
 }
@@ -0,0 +1,174 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+)
+
+const (
+	// LabelRetainedFrom is set on the PVCs kept by the Retain reclaim policy
+	// to the name of the deleted instance
+	LabelRetainedFrom = "in-cluster.crossplane.io/retained-from"
+
+	errSnapshotFailed = "snapshot %s failed: %s"
+)
+
+// VolumeSnapshotGroupVersionKind is the kind of the CSI snapshots taken of
+// the PVCs of an instance
+var VolumeSnapshotGroupVersionKind = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1beta1",
+	Kind:    "VolumeSnapshot",
+}
+
+// ReclaimPolicy returns what happens to the data of the given instance when
+// it is deleted
+func ReclaimPolicy(ps *v1alpha1.Postgres) v1alpha1.PostgresReclaimPolicy {
+	if ps.Spec.ForProvider.ReclaimPolicy == nil {
+		return v1alpha1.ReclaimPolicyDelete
+	}
+	return *ps.Spec.ForProvider.ReclaimPolicy
+}
+
+// FinalSnapshotName returns the name of the snapshot taken of the PVC with the
+// given name when its instance is deleted
+func FinalSnapshotName(claim string) string {
+	return claim + "-final"
+}
+
+// MakeFinalSnapshot creates the VolumeSnapshot of the PVC with the given name
+// taken when the given instance is deleted
+func MakeFinalSnapshot(ps *v1alpha1.Postgres, claim string) *unstructured.Unstructured {
+	return makeVolumeSnapshot(objectMeta(ps, FinalSnapshotName(claim)), claim, ps.Spec.ForProvider.VolumeSnapshotClassName)
+}
+
+// IsSnapshotReady returns true once the given VolumeSnapshot can be restored,
+// and an error if the CSI driver failed to take it
+func IsSnapshotReady(u *unstructured.Unstructured) (bool, error) {
+	if msg, ok, _ := unstructured.NestedString(u.Object, "status", "error", "message"); ok && msg != "" {
+		return false, errors.Errorf(errSnapshotFailed, u.GetName(), msg)
+	}
+	ready, _, _ := unstructured.NestedBool(u.Object, "status", "readyToUse")
+	return ready, nil
+}
+
+// DataClaims returns the PVCs holding the data of the given instance, i.e.
+// the PVC of a single instance if it owns it, or the PVCs of all pods of a
+// replicated instance
+func (c postgresClient) DataClaims(ctx context.Context, ps *v1alpha1.Postgres) ([]v1.PersistentVolumeClaim, error) {
+	if IsReplicated(ps) {
+		l := &v1.PersistentVolumeClaimList{}
+		err := c.kube.List(ctx, l, client.InNamespace(ps.Namespace), client.MatchingLabels{LabelStatefulSet: ps.Name})
+		return l.Items, err
+	}
+	pvc := v1.PersistentVolumeClaim{}
+	err := c.kube.Get(ctx, client.ObjectKey{Namespace: ps.Namespace, Name: ps.Name}, &pvc)
+	if kerrors.IsNotFound(err) || (err == nil && !clients.IsOwnedBy(&pvc, ps)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []v1.PersistentVolumeClaim{pvc}, nil
+}
+
+// RetainPostgresPVC detaches the PVCs of the given instance from it instead
+// of deleting them. They are labelled with the name of the instance and
+// annotated for adoption, so that a new instance of the same name picks them
+// up again. The copy taken before a major version upgrade is deleted.
+func (c postgresClient) RetainPostgresPVC(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if err := c.deleteOwnedPVC(ctx, ps, PreUpgradeSnapshotName(ps)); err != nil {
+		return err
+	}
+	claims, err := c.DataClaims(ctx, ps)
+	if err != nil {
+		return err
+	}
+	for i := range claims {
+		pvc := &claims[i]
+		if pvc.Labels[LabelRetainedFrom] == ps.Name {
+			continue
+		}
+		DetachPVC(ps, pvc)
+		if err := c.kube.Update(ctx, pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DetachPVC removes the owner of the given PVC and marks it as retained from
+// the given instance
+func DetachPVC(ps *v1alpha1.Postgres, pvc *v1.PersistentVolumeClaim) {
+	for _, k := range []string{clients.LabelOwnerUID, clients.LabelOwnerKind} {
+		delete(pvc.Labels, k)
+	}
+	for _, k := range []string{clients.AnnotationOwnerAPIVersion, clients.AnnotationOwnerName} {
+		delete(pvc.Annotations, k)
+	}
+	pvc.Labels = mergeStringMap(pvc.Labels, map[string]string{LabelRetainedFrom: ps.Name})
+	pvc.Annotations = mergeStringMap(pvc.Annotations, map[string]string{clients.AnnotationAdopt: "true"})
+}
+
+// SnapshotPostgresPVC takes a VolumeSnapshot of each PVC of the given
+// instance unless it exists already. It returns the names of the snapshots
+// and whether all of them are ready to use.
+func (c postgresClient) SnapshotPostgresPVC(ctx context.Context, ps *v1alpha1.Postgres) ([]string, bool, error) {
+	claims, err := c.DataClaims(ctx, ps)
+	if err != nil {
+		return nil, false, err
+	}
+	names := make([]string, 0, len(claims))
+	ready := true
+	for _, pvc := range claims {
+		desired := MakeFinalSnapshot(ps, pvc.Name)
+		names = append(names, desired.GetName())
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(VolumeSnapshotGroupVersionKind)
+		err := c.kube.Get(ctx, client.ObjectKey{Namespace: desired.GetNamespace(), Name: desired.GetName()}, current)
+		if kerrors.IsNotFound(err) {
+			if err := c.kube.Create(ctx, desired); err != nil {
+				return names, false, err
+			}
+			ready = false
+			continue
+		}
+		if err != nil {
+			return names, false, err
+		}
+		if err := clients.CheckAdoption(current, desired); err != nil {
+			return names, false, err
+		}
+		ok, err := IsSnapshotReady(current)
+		if err != nil {
+			return names, false, err
+		}
+		ready = ready && ok
+	}
+	return names, ready, nil
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	// LabelStatefulSet selects the pods and PVCs of a replicated instance
+	LabelStatefulSet = "statefulset"
+	// LabelRole marks pods of a replicated instance as primary or replica
+	LabelRole = "in-cluster.crossplane.io/role"
+	// RolePrimary is the role of the pod accepting writes
+	RolePrimary = "primary"
+	// RoleReplica is the role of the hot standby pods
+	RoleReplica = "replica"
+	// ReplicationPrimaryKey is the key of the replication ConfigMap holding the
+	// name of the primary pod
+	ReplicationPrimaryKey = "primary"
+
+	labelPodName          = "statefulset.kubernetes.io/pod-name"
+	dataVolumeName        = "data"
+	replicationVolumeName = "replication"
+	replicationMountPath  = "/etc/postgres-replication"
+	initdbMountPath       = "/docker-entrypoint-initdb.d"
+	bootstrapScript       = "bootstrap.sh"
+	enableReplication     = "enable-replication.sh"
+)
+
+// bootstrapScriptTemplate starts a pod of a replicated instance. Pods other
+// than the primary clone the primary with pg_basebackup on their first start,
+// or when they ran as primary before a failover, and then run as hot standby.
+// The standbys take the master password from the environment instead of
+// their primary_conninfo, so that they follow a rotation of the password.
+const bootstrapScriptTemplate = `#!/bin/bash
+set -e
+primary="$(cat %[1]s/%[2]s)"
+if [ "$HOSTNAME" != "$primary" ] && [ -s "$PGDATA/PG_VERSION" ] && [ ! -f "$PGDATA/standby.signal" ]; then
+  # a former primary which was failed over has diverged from the new primary
+  # and is cloned again, its data is kept until the next failover
+  rm -rf "$PGDATA.old"
+  mv "$PGDATA" "$PGDATA.old"
+fi
+if [ "$HOSTNAME" != "$primary" ] && [ ! -s "$PGDATA/PG_VERSION" ]; then
+  mkdir -p "$PGDATA"
+  until pg_isready -q -h %[3]s -p %[4]d; do sleep 2; done
+  PGPASSWORD="$POSTGRES_PASSWORD" pg_basebackup -h %[3]s -p %[4]d -U "$POSTGRES_USER" -D "$PGDATA" -R -X stream
+  chown -R postgres:postgres "$PGDATA"
+  chmod 700 "$PGDATA"
+fi
+if [ "$HOSTNAME" != "$primary" ]; then
+  sed -i '/^primary_conninfo/d' "$PGDATA/postgresql.auto.conf"
+  echo "primary_conninfo = 'host=%[3]s port=%[4]d user=$POSTGRES_USER'" >> "$PGDATA/postgresql.auto.conf"
+  export PGPASSWORD="$POSTGRES_PASSWORD"
+fi
+exec docker-entrypoint.sh "$@"
+`
+
+// enableReplicationScript runs once when the primary is initialized and allows
+// the standbys to connect for streaming replication.
+const enableReplicationScript = `#!/bin/bash
+echo "host replication all all md5" >> "$PGDATA/pg_hba.conf"
+`
+
+// IsReplicated returns true if the given instance runs as a StatefulSet with
+// hot standby replicas
+func IsReplicated(ps *v1alpha1.Postgres) bool {
+	return ps.Spec.ForProvider.Replicas != nil
+}
+
+// PrimaryPodName returns the name of the pod the primary of a replicated
+// instance is initially run in
+func PrimaryPodName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-0"
+}
+
+// PodOrdinal returns the ordinal of a pod of the StatefulSet of the given
+// instance, or -1 if the name does not belong to one of its pods
+func PodOrdinal(ps *v1alpha1.Postgres, pod string) int {
+	i, err := strconv.Atoi(strings.TrimPrefix(pod, ps.Name+"-"))
+	if err != nil || !strings.HasPrefix(pod, ps.Name+"-") {
+		return -1
+	}
+	return i
+}
+
+// ReadOnlyServiceName returns the name of the Service in front of the replicas
+func ReadOnlyServiceName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-ro"
+}
+
+// ReplicationConfigMapName returns the name of the ConfigMap holding the
+// replication scripts and the name of the primary pod
+func ReplicationConfigMapName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-replication"
+}
+
+// ClaimName returns the name of the PVC created by the StatefulSet for the pod
+// with the given ordinal
+func ClaimName(ps *v1alpha1.Postgres, ordinal int) string {
+	return fmt.Sprintf("%s-%s-%d", dataVolumeName, ps.Name, ordinal)
+}
+
+// PrimaryHost returns the cluster internal hostname of the primary Service
+func PrimaryHost(ps *v1alpha1.Postgres) string {
+	return ServiceHost(ps.Name, ps.Namespace)
+}
+
+// MakeReplicationConfigMap creates the ConfigMap with the replication scripts
+// and the name of the primary pod
+func MakeReplicationConfigMap(ps *v1alpha1.Postgres, primary string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: objectMeta(ps, ReplicationConfigMapName(ps)),
+		Data: map[string]string{
+			ReplicationPrimaryKey: primary,
+			bootstrapScript: fmt.Sprintf(bootstrapScriptTemplate, replicationMountPath, ReplicationPrimaryKey,
+				PrimaryHost(ps), utils.IntValue(ps.Spec.ForProvider.Port)),
+			enableReplication: enableReplicationScript,
+		},
+	}
+}
+
+// MakePostgresStatefulSet creates the StatefulSet running the primary and the
+// hot standby replicas
+func MakePostgresStatefulSet(ps *v1alpha1.Postgres) (*appsv1.StatefulSet, error) {
+	pvc, err := MakePVCPostgres(ps)
+	if err != nil {
+		return nil, err
+	}
+	pvc.TypeMeta = metav1.TypeMeta{}
+	pvc.ObjectMeta = metav1.ObjectMeta{Name: dataVolumeName}
+
+	containers := MakeDefaultPostgresPodContainers(ps)
+	containers[0].Command = []string{"/bin/bash", replicationMountPath + "/" + bootstrapScript}
+	containers[0].VolumeMounts = []v1.VolumeMount{
+		{
+			Name:      dataVolumeName,
+			MountPath: dataMountPath,
+		},
+		{
+			Name:      replicationVolumeName,
+			MountPath: replicationMountPath,
+		},
+		{
+			Name:      replicationVolumeName,
+			MountPath: initdbMountPath + "/" + enableReplication,
+			SubPath:   enableReplication,
+		},
+		{
+			Name:      configVolumeName,
+			MountPath: configMountPath,
+			ReadOnly:  true,
+		},
+	}
+
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: objectMeta(ps, ps.Name),
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: ps.Name,
+			Replicas:    utils.Int32(int32(utils.IntValue(ps.Spec.ForProvider.Replicas) + 1)),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					LabelStatefulSet: ps.Name,
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						LabelStatefulSet: ps.Name,
+					},
+					Annotations: map[string]string{
+						AnnotationRestartParameters: RestartParameters(ps),
+					},
+				},
+				Spec: v1.PodSpec{
+					Volumes: []v1.Volume{
+						{
+							Name: replicationVolumeName,
+							VolumeSource: v1.VolumeSource{
+								ConfigMap: &v1.ConfigMapVolumeSource{
+									LocalObjectReference: v1.LocalObjectReference{Name: ReplicationConfigMapName(ps)},
+								},
+							},
+						},
+						configVolume(ps),
+					},
+					Containers: containers,
+				},
+			},
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{*pvc},
+		},
+	}
+	sts.Annotations[AnnotationVersion] = Version(ps)
+	addScheduling(ps, &sts.Spec.Template)
+	addTLS(ps, &sts.Spec.Template)
+	restrictPodSecurity(ps.Status.AtProvider.PodSecurityProfile, &sts.Spec.Template, true)
+	return sts, nil
+}
+
+// MakePrimaryPostgresService creates the Service of a replicated instance which
+// points at the primary pod
+func MakePrimaryPostgresService(ps *v1alpha1.Postgres, primary string) *v1.Service {
+	svc := MakeDefaultPostgresService(ps)
+	svc.Spec.Selector = map[string]string{labelPodName: primary}
+	return svc
+}
+
+// MakeReadOnlyPostgresService creates the Service which balances read-only
+// connections across the hot standby replicas
+func MakeReadOnlyPostgresService(ps *v1alpha1.Postgres) *v1.Service {
+	svc := &v1.Service{
+		ObjectMeta: objectMeta(ps, ReadOnlyServiceName(ps)),
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:       "postgresql",
+					Protocol:   v1.ProtocolTCP,
+					Port:       int32(utils.IntValue(ps.Spec.ForProvider.Port)),
+					TargetPort: intstr.FromInt(DefaultPostgresPort),
+				},
+			},
+			Selector: map[string]string{
+				LabelStatefulSet: ps.Name,
+				LabelRole:        RoleReplica,
+			},
+		},
+	}
+	exposeService(ps, svc)
+	return svc
+}
+
+// PodRole returns the role the given pod should be labelled with
+func PodRole(pod *v1.Pod, primary string) string {
+	if pod.Name == primary {
+		return RolePrimary
+	}
+	return RoleReplica
+}
+
+// IsStatefulSetUpToDate checks whether the observed StatefulSet still matches
+// the desired one
+func IsStatefulSetUpToDate(desired, observed *appsv1.StatefulSet) bool {
+	return utils.Int32Value(desired.Spec.Replicas) == utils.Int32Value(observed.Spec.Replicas) &&
+		equality.Semantic.DeepDerivative(desired.Annotations, observed.Annotations) &&
+		equality.Semantic.DeepDerivative(desired.Spec.Template, observed.Spec.Template) &&
+		hasRestartParameters(desired.Spec.Template, observed.Spec.Template) &&
+		hasScheduling(desired.Spec.Template, observed.Spec.Template) &&
+		hasPodSecurity(desired.Spec.Template, observed.Spec.Template)
+}
+
+// IsConfigMapUpToDate checks whether the observed ConfigMap still holds the
+// desired data
+func IsConfigMapUpToDate(desired, observed *v1.ConfigMap) bool {
+	return equality.Semantic.DeepEqual(desired.Data, observed.Data)
+}
+
+// IsPodReady returns true if the given pod is ready to serve connections
+func IsPodReady(pod *v1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// ReplayPositionCommand prints the WAL position a standby has received, or
+// replayed if streaming stopped, as number of bytes
+const ReplayPositionCommand = `psql -U "$POSTGRES_USER" -d postgres -tAc ` +
+	`"SELECT pg_wal_lsn_diff(COALESCE(pg_last_wal_receive_lsn(), pg_last_wal_replay_lsn()), '0/0')"`
+
+// PromoteCommand promotes a standby to primary
+const PromoteCommand = `psql -U "$POSTGRES_USER" -d postgres -tAc "SELECT pg_promote()"`
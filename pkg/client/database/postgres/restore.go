@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"path"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	restoreVolumeName = "restore"
+	restoreMountPath  = "/restore"
+)
+
+// restoreScript waits for the new instance to accept connections and restores
+// the source into it. Restoring all roles of a pg_dumpall dump also restores
+// the password of the master user of the source, so it is set back to the
+// password of the new instance in the same session.
+const restoreScript = `set -eo pipefail
+until pg_isready -q; do sleep 2; done
+restore_sql() {
+  { cat; echo "ALTER ROLE CURRENT_USER PASSWORD :'password';"; } | psql -v password="$PGPASSWORD" -d postgres
+}
+if [ -n "$SOURCE_PGHOST" ]; then
+  PGPASSWORD="$SOURCE_PGPASSWORD" pg_dumpall -h "$SOURCE_PGHOST" -p "$SOURCE_PGPORT" -U "$SOURCE_PGUSER" | restore_sql
+  exit 0
+fi
+case "$RESTORE_FILE" in
+  *.dump) pg_restore --no-owner --no-privileges -d "$PGDATABASE" "$RESTORE_FILE" ;;
+  *.gz) gunzip -c "$RESTORE_FILE" | restore_sql ;;
+  *) restore_sql < "$RESTORE_FILE" ;;
+esac
+`
+
+// s3DownloadScript downloads S3_URL to RESTORE_FILE
+const s3DownloadScript = `set -eo pipefail
+if [ -n "$S3_ENDPOINT" ]; then
+  aws --endpoint-url "$S3_ENDPOINT" s3 cp "$S3_URL" "$RESTORE_FILE"
+else
+  aws s3 cp "$S3_URL" "$RESTORE_FILE"
+fi
+`
+
+// RestoreSource is the resolved source a new instance is restored from.
+// Either File is set together with ClaimName or S3, or Instance is set.
+type RestoreSource struct {
+	// File is the path of the dump inside the claim or its S3 URL
+	File      string
+	ClaimName string
+	S3        *v1alpha1.PostgresBackupS3Destination
+
+	// Instance is cloned by logging in with Password, or with the password
+	// Secret of Instance if it runs in the same namespace
+	Instance *v1alpha1.Postgres
+	Password string
+}
+
+// String describes the source for the status of the instance
+func (s *RestoreSource) String() string {
+	switch {
+	case s.Instance != nil:
+		return "postgres/" + s.Instance.Name
+	case s.ClaimName != "":
+		return "pvc/" + s.ClaimName + "/" + s.File
+	}
+	return s.File
+}
+
+// RestoreJobName returns the name of the Job restoring a new instance from its
+// source
+func RestoreJobName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-restore"
+}
+
+// MakePostgresRestoreJob creates the Job which restores the given source into
+// the new instance through its primary Service. A failed restore leaves a
+// partially initialized instance behind, so the Job is not retried.
+func MakePostgresRestoreJob(ps *v1alpha1.Postgres, src *RestoreSource) *batchv1.Job {
+	container := v1.Container{
+		Name:    "restore",
+		Image:   Image(ps),
+		Command: []string{"/bin/bash", "-c", restoreScript},
+		Env: []v1.EnvVar{
+			envVarFromValue("PGHOST", PrimaryHost(ps)),
+			envVarFromValue("PGPORT", strconv.Itoa(Port(ps))),
+			envVarFromValue("PGUSER", MasterUsername(ps)),
+			envVarFromSecret("PGPASSWORD", PasswordSecretName(ps), PasswordSecretKey),
+			envVarFromValue("PGDATABASE", utils.StringValueFallback(ps.Spec.ForProvider.Database, MasterUsername(ps))),
+		},
+	}
+	spec := v1.PodSpec{RestartPolicy: v1.RestartPolicyNever}
+
+	switch {
+	case src.Instance != nil:
+		// the password Secret of a source in another namespace cannot be
+		// referenced
+		pw := envVarFromValue("SOURCE_PGPASSWORD", src.Password)
+		if src.Instance.Namespace == ps.Namespace {
+			pw = envVarFromSecret("SOURCE_PGPASSWORD", PasswordSecretName(src.Instance), PasswordSecretKey)
+		}
+		container.Env = append(container.Env,
+			envVarFromValue("SOURCE_PGHOST", PrimaryHost(src.Instance)),
+			envVarFromValue("SOURCE_PGPORT", strconv.Itoa(Port(src.Instance))),
+			envVarFromValue("SOURCE_PGUSER", MasterUsername(src.Instance)),
+			pw)
+	case src.S3 != nil:
+		file := envVarFromValue("RESTORE_FILE", restoreMountPath+"/"+path.Base(src.File))
+		container.Env = append(container.Env, file)
+		container.VolumeMounts = []v1.VolumeMount{{Name: restoreVolumeName, MountPath: restoreMountPath}}
+		env := []v1.EnvVar{file, envVarFromValue("S3_URL", src.File)}
+		if src.S3.Endpoint != nil {
+			env = append(env, envVarFromValue("S3_ENDPOINT", *src.S3.Endpoint))
+		}
+		if src.S3.Region != nil {
+			env = append(env, envVarFromValue("AWS_DEFAULT_REGION", *src.S3.Region))
+		}
+		spec.Volumes = []v1.Volume{{
+			Name:         restoreVolumeName,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		}}
+		spec.InitContainers = []v1.Container{{
+			Name:    "download",
+			Image:   ImageS3Upload,
+			Command: []string{"/bin/bash", "-c", s3DownloadScript},
+			Env:     env,
+			EnvFrom: []v1.EnvFromSource{{
+				SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: src.S3.CredentialsSecretName}},
+			}},
+			VolumeMounts: []v1.VolumeMount{{Name: restoreVolumeName, MountPath: restoreMountPath}},
+		}}
+	default:
+		container.Env = append(container.Env, envVarFromValue("RESTORE_FILE", restoreMountPath+"/"+src.File))
+		container.VolumeMounts = []v1.VolumeMount{{Name: restoreVolumeName, MountPath: restoreMountPath, ReadOnly: true}}
+		spec.Volumes = []v1.Volume{{
+			Name: restoreVolumeName,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: src.ClaimName, ReadOnly: true},
+			},
+		}}
+	}
+	spec.Containers = []v1.Container{container}
+
+	job := &batchv1.Job{
+		ObjectMeta: objectMeta(ps, RestoreJobName(ps)),
+		Spec: batchv1.JobSpec{
+			BackoffLimit: utils.Int32(0),
+			Template:     v1.PodTemplateSpec{Spec: spec},
+		},
+	}
+	restrictPodSecurity(ps.Status.AtProvider.PodSecurityProfile, &job.Spec.Template, true)
+	return job
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+// zoneSpreadWeight is the weight of the preferred pod anti-affinity spreading
+// the pods of an instance across zones
+const zoneSpreadWeight = 100
+
+// addScheduling sets the node selector, tolerations, affinity, topology
+// spread constraints and priority of the given instance on the template of
+// its pods. The pods are selected by the labels of the template.
+func addScheduling(ps *v1alpha1.Postgres, tpl *v1.PodTemplateSpec) {
+	p := ps.Spec.ForProvider
+	addNodePlacement(ps, &tpl.Spec)
+	if p.Affinity != nil {
+		tpl.Spec.Affinity = p.Affinity.DeepCopy()
+	}
+	if utils.BoolValueFallback(p.SpreadAcrossZones, false) {
+		if tpl.Spec.Affinity == nil {
+			tpl.Spec.Affinity = &v1.Affinity{}
+		}
+		if tpl.Spec.Affinity.PodAntiAffinity == nil {
+			tpl.Spec.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+		anti := tpl.Spec.Affinity.PodAntiAffinity
+		anti.PreferredDuringSchedulingIgnoredDuringExecution = append(anti.PreferredDuringSchedulingIgnoredDuringExecution,
+			v1.WeightedPodAffinityTerm{
+				Weight: zoneSpreadWeight,
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: podSelector(tpl),
+					TopologyKey:   v1.LabelZoneFailureDomainStable,
+				},
+			})
+	}
+	for _, c := range p.TopologySpreadConstraints {
+		c = *c.DeepCopy()
+		if c.LabelSelector == nil {
+			c.LabelSelector = podSelector(tpl)
+		}
+		tpl.Spec.TopologySpreadConstraints = append(tpl.Spec.TopologySpreadConstraints, c)
+	}
+	tpl.Spec.PriorityClassName = utils.StringValue(p.PriorityClassName)
+}
+
+// addNodePlacement restricts the given pod spec to the nodes the pods of the
+// given instance may run on, i.e. its node selector, tolerations and node
+// affinity. It is used for the pods mounting the data of the instance next to
+// its own pods.
+func addNodePlacement(ps *v1alpha1.Postgres, spec *v1.PodSpec) {
+	p := ps.Spec.ForProvider
+	if len(p.NodeSelector) > 0 {
+		spec.NodeSelector = make(map[string]string, len(p.NodeSelector))
+		for k, v := range p.NodeSelector {
+			spec.NodeSelector[k] = v
+		}
+	}
+	for _, t := range p.Tolerations {
+		spec.Tolerations = append(spec.Tolerations, *t.DeepCopy())
+	}
+	if p.Affinity != nil && p.Affinity.NodeAffinity != nil {
+		spec.Affinity = &v1.Affinity{NodeAffinity: p.Affinity.NodeAffinity.DeepCopy()}
+	}
+}
+
+func podSelector(tpl *v1.PodTemplateSpec) *metav1.LabelSelector {
+	labels := make(map[string]string, len(tpl.Labels))
+	for k, v := range tpl.Labels {
+		labels[k] = v
+	}
+	return &metav1.LabelSelector{MatchLabels: labels}
+}
+
+// hasScheduling checks that the observed pod template is scheduled as the
+// desired one. DeepDerivative ignores scheduling fields which are removed.
+func hasScheduling(desired, observed v1.PodTemplateSpec) bool {
+	d, o := desired.Spec, observed.Spec
+	return equality.Semantic.DeepEqual(d.NodeSelector, o.NodeSelector) &&
+		equality.Semantic.DeepEqual(d.Tolerations, o.Tolerations) &&
+		equality.Semantic.DeepEqual(d.Affinity, o.Affinity) &&
+		equality.Semantic.DeepEqual(d.TopologySpreadConstraints, o.TopologySpreadConstraints) &&
+		d.PriorityClassName == o.PriorityClassName
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/v1beta1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	// PostgresUID is the UID and GID of the postgres user of the official
+	// postgres image, which the pods run as with the restricted profile
+	PostgresUID = 999
+	// AnnotationPodSecurity records the security profile a pod template was
+	// rendered with
+	AnnotationPodSecurity = "in-cluster.crossplane.io/pod-security"
+
+	tmpVolumeName     = "tmp"
+	tmpMountPath      = "/tmp"
+	runVolumeName     = "var-run"
+	runMountPath      = "/var/run/postgresql"
+	capabilityAll     = "ALL"
+	restrictedProfile = string(v1beta1.PodSecurityProfileRestricted)
+)
+
+// isRestricted returns true if pods are rendered with the restricted profile
+// for the given security profile
+func isRestricted(profile string) bool {
+	return profile == restrictedProfile
+}
+
+// restrictPodSecurity hardens the given pod template to the restricted Pod
+// Security Standard if the given profile asks for it: all containers run as
+// the postgres user without privilege escalation and capabilities, with the
+// RuntimeDefault seccomp profile. Containers which only write to their
+// volumes get a read-only root filesystem, with emptyDirs for /tmp and the
+// socket directory of Postgres.
+func restrictPodSecurity(profile string, tpl *v1.PodTemplateSpec, readOnlyRoot bool) {
+	if !isRestricted(profile) {
+		return
+	}
+	uid := int64(PostgresUID)
+	// the seccompProfile field of the security context is not part of the
+	// API version the provider is built against, so the seccomp profile is
+	// only requested with the annotation, which Kubernetes deprecated in 1.19
+	tpl.Annotations = mergeStringMap(tpl.Annotations, map[string]string{
+		AnnotationPodSecurity:      restrictedProfile,
+		v1.SeccompPodAnnotationKey: v1.SeccompProfileRuntimeDefault,
+	})
+	tpl.Spec.SecurityContext = &v1.PodSecurityContext{
+		RunAsNonRoot: utils.Bool(true),
+		RunAsUser:    &uid,
+		RunAsGroup:   &uid,
+		FSGroup:      &uid,
+	}
+	if readOnlyRoot {
+		tpl.Spec.Volumes = append(tpl.Spec.Volumes,
+			v1.Volume{Name: tmpVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+			v1.Volume{Name: runVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}})
+	}
+	restrict := func(c *v1.Container) {
+		c.SecurityContext = &v1.SecurityContext{
+			RunAsNonRoot:             utils.Bool(true),
+			AllowPrivilegeEscalation: utils.Bool(false),
+			ReadOnlyRootFilesystem:   utils.Bool(readOnlyRoot),
+			Capabilities:             &v1.Capabilities{Drop: []v1.Capability{capabilityAll}},
+		}
+		if readOnlyRoot {
+			c.VolumeMounts = append(c.VolumeMounts,
+				v1.VolumeMount{Name: tmpVolumeName, MountPath: tmpMountPath},
+				v1.VolumeMount{Name: runVolumeName, MountPath: runMountPath})
+		}
+	}
+	for i := range tpl.Spec.InitContainers {
+		restrict(&tpl.Spec.InitContainers[i])
+	}
+	for i := range tpl.Spec.Containers {
+		restrict(&tpl.Spec.Containers[i])
+	}
+}
+
+// hasPodSecurity checks that the observed pod template was rendered with the
+// security profile of the desired one. DeepDerivative ignores the security
+// context once the restricted profile is turned off.
+func hasPodSecurity(desired, observed v1.PodTemplateSpec) bool {
+	return desired.Annotations[AnnotationPodSecurity] == observed.Annotations[AnnotationPodSecurity]
+}
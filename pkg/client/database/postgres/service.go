@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+)
+
+// AnnotationServiceAnnotations records the keys of the serviceAnnotations
+// of an instance on its Services, so that annotations which are removed from
+// the spec are removed from the Services as well, while annotations set by
+// others are kept
+const AnnotationServiceAnnotations = "in-cluster.crossplane.io/service-annotations"
+
+// ServiceHost returns the DNS name of the Service with the given name and
+// namespace, which is stable as long as the Service exists. The domain of the
+// cluster is left out, the search path of the pods completes the name in
+// clusters with any domain.
+func ServiceHost(name, namespace string) string {
+	return name + "." + namespace + ".svc"
+}
+
+// ServiceType returns how the Services of the given instance are exposed
+func ServiceType(ps *v1alpha1.Postgres) v1alpha1.PostgresServiceType {
+	if ps.Spec.ForProvider.ServiceType == nil {
+		return v1alpha1.ServiceTypeClusterIP
+	}
+	return *ps.Spec.ForProvider.ServiceType
+}
+
+// exposeService sets the type, annotations and source ranges of the given
+// instance on one of its Services
+func exposeService(ps *v1alpha1.Postgres, svc *v1.Service) {
+	switch t := ServiceType(ps); t {
+	case v1alpha1.ServiceTypeHeadless:
+		svc.Spec.Type = v1.ServiceTypeClusterIP
+		svc.Spec.ClusterIP = v1.ClusterIPNone
+	default:
+		svc.Spec.Type = v1.ServiceType(t)
+	}
+	if len(ps.Spec.ForProvider.ServiceAnnotations) > 0 {
+		svc.Annotations = make(map[string]string, len(ps.Spec.ForProvider.ServiceAnnotations)+1)
+		for k, v := range ps.Spec.ForProvider.ServiceAnnotations {
+			svc.Annotations[k] = v
+		}
+		svc.Annotations[AnnotationServiceAnnotations] = strings.Join(sortedKeys(ps.Spec.ForProvider.ServiceAnnotations), ",")
+	}
+	if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+		svc.Spec.LoadBalancerSourceRanges = append([]string(nil), ps.Spec.ForProvider.LoadBalancerSourceRanges...)
+	}
+}
+
+// mergeServiceAnnotations adds the desired annotations of a Service to the
+// current ones after removing those which were recorded as serviceAnnotations
+// on the current Service but are no longer desired
+func mergeServiceAnnotations(current, desired map[string]string) map[string]string {
+	for _, k := range strings.Split(current[AnnotationServiceAnnotations], ",") {
+		if _, ok := desired[k]; !ok {
+			delete(current, k)
+		}
+	}
+	delete(current, AnnotationServiceAnnotations)
+	return mergeStringMap(current, desired)
+}
+
+// IsHeadless returns true if the given Service has no cluster IP
+func IsHeadless(svc *v1.Service) bool {
+	return svc.Spec.ClusterIP == v1.ClusterIPNone
+}
+
+// mergeServicePorts returns the desired ports keeping the node ports
+// allocated for the current ones, so that updating a NodePort or
+// LoadBalancer Service does not move it to another node port
+func mergeServicePorts(current, desired []v1.ServicePort, t v1.ServiceType) []v1.ServicePort {
+	ports := append([]v1.ServicePort(nil), desired...)
+	if t != v1.ServiceTypeNodePort && t != v1.ServiceTypeLoadBalancer {
+		return ports
+	}
+	for i := range ports {
+		for _, p := range current {
+			if p.Name == ports[i].Name && ports[i].NodePort == 0 {
+				ports[i].NodePort = p.NodePort
+			}
+		}
+	}
+	return ports
+}
+
+// ServiceAddress returns the host and port clients reach the given Service
+// of an instance at from outside the Service. It returns an empty host while
+// the address is not known yet, e.g. the load balancer is provisioned. The
+// nodes are only needed for a NodePort Service.
+func ServiceAddress(svc *v1.Service, nodes []v1.Node) (string, int) {
+	if len(svc.Spec.Ports) == 0 {
+		return "", 0
+	}
+	port := svc.Spec.Ports[0]
+	switch {
+	case IsHeadless(svc):
+		// clients connect to the pods directly
+		return ServiceHost(svc.Name, svc.Namespace), port.TargetPort.IntValue()
+	case svc.Spec.Type == v1.ServiceTypeLoadBalancer:
+		for _, in := range svc.Status.LoadBalancer.Ingress {
+			if in.IP != "" {
+				return in.IP, int(port.Port)
+			}
+			if in.Hostname != "" {
+				return in.Hostname, int(port.Port)
+			}
+		}
+		return "", 0
+	case svc.Spec.Type == v1.ServiceTypeNodePort:
+		if port.NodePort == 0 {
+			return "", 0
+		}
+		return NodeAddress(nodes), int(port.NodePort)
+	}
+	return svc.Spec.ClusterIP, int(port.Port)
+}
+
+// NodeAddress returns the address of a ready node a node port can be reached
+// at. External IPs are preferred over internal ones, nodes are chosen by name
+// so that the address does not change between reconciles.
+func NodeAddress(nodes []v1.Node) string {
+	sorted := append([]v1.Node(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, t := range []v1.NodeAddressType{v1.NodeExternalIP, v1.NodeInternalIP} {
+		for i := range sorted {
+			if !isNodeReady(&sorted[i]) {
+				continue
+			}
+			for _, a := range sorted[i].Status.Addresses {
+				if a.Type == t && a.Address != "" {
+					return a.Address
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isNodeReady(n *v1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// IsServiceUpToDate checks whether the observed Service still matches the
+// desired one. The node ports allocated by the API server are not compared,
+// serviceAnnotations which were removed are detected by their record.
+func IsServiceUpToDate(desired, observed *v1.Service) bool {
+	d := desired.DeepCopy()
+	d.Spec.Ports = mergeServicePorts(observed.Spec.Ports, d.Spec.Ports, d.Spec.Type)
+	return equality.Semantic.DeepDerivative(d.Spec, observed.Spec) &&
+		equality.Semantic.DeepDerivative(d.Annotations, observed.Annotations) &&
+		d.Annotations[AnnotationServiceAnnotations] == observed.Annotations[AnnotationServiceAnnotations] &&
+		len(d.Spec.LoadBalancerSourceRanges) == len(observed.Spec.LoadBalancerSourceRanges) &&
+		len(d.Spec.Ports) == len(observed.Spec.Ports)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+)
+
+const (
+	// CheckpointQuery flushes all dirty buffers to disk, so that an instance
+	// restored from a snapshot taken right after only replays little WAL
+	CheckpointQuery = "CHECKPOINT"
+
+	errNoPrimary         = "the primary of postgres %s is not known yet"
+	errRestoreSize       = "the restore size %s of snapshot %s exceeds the database size %s"
+	errSnapshotReplicas  = "restoring from a snapshot is only supported for instances without replicas"
+	errSnapshotNamespace = "snapshot %s is in namespace %q, the instance in namespace %q"
+)
+
+// SnapshotClaimName returns the name of the PVC a snapshot of the given
+// instance is taken of, i.e. the PVC of its primary if it is replicated
+func SnapshotClaimName(ps *v1alpha1.Postgres) (string, error) {
+	if !IsReplicated(ps) {
+		return ps.Name, nil
+	}
+	if ps.Status.AtProvider.Primary == "" {
+		return "", errors.Errorf(errNoPrimary, ps.Name)
+	}
+	return ClaimNameForPod(ps.Status.AtProvider.Primary), nil
+}
+
+// MakeSnapshot creates the VolumeSnapshot of the given PostgresSnapshot
+// taken of the PVC with the given name of the given instance
+func MakeSnapshot(cr *v1alpha1.PostgresSnapshot, ps *v1alpha1.Postgres, claim string) *unstructured.Unstructured {
+	om := metav1.ObjectMeta{Name: cr.Name, Namespace: ps.Namespace}
+	clients.SetOwner(&om, cr, v1alpha1.PostgresSnapshotGroupVersionKind)
+	return makeVolumeSnapshot(om, claim, cr.Spec.ForProvider.VolumeSnapshotClassName)
+}
+
+// makeVolumeSnapshot creates a VolumeSnapshot of the PVC with the given name
+func makeVolumeSnapshot(om metav1.ObjectMeta, claim string, class *string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(VolumeSnapshotGroupVersionKind)
+	u.SetName(om.Name)
+	u.SetNamespace(om.Namespace)
+	u.SetLabels(om.Labels)
+	u.SetAnnotations(om.Annotations)
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claim},
+	}
+	if class != nil {
+		spec["volumeSnapshotClassName"] = *class
+	}
+	u.Object["spec"] = spec
+	return u
+}
+
+// SetSnapshotStatus records the state of the given VolumeSnapshot in the
+// status of the PostgresSnapshot
+func SetSnapshotStatus(cr *v1alpha1.PostgresSnapshot, u *unstructured.Unstructured) {
+	s := &cr.Status.AtProvider
+	s.Namespace = u.GetNamespace()
+	s.SourcePVC, _, _ = unstructured.NestedString(u.Object, "spec", "source", "persistentVolumeClaimName")
+	s.ReadyToUse, _, _ = unstructured.NestedBool(u.Object, "status", "readyToUse")
+	s.RestoreSize, _, _ = unstructured.NestedString(u.Object, "status", "restoreSize")
+	s.CreationTime = nil
+	if ts, ok, _ := unstructured.NestedString(u.Object, "status", "creationTime"); ok {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			mt := metav1.NewTime(t)
+			s.CreationTime = &mt
+		}
+	}
+}
+
+// SnapshotDataSource returns the data source of a PVC provisioned from the
+// VolumeSnapshot of the PostgresSnapshot with the given name
+func SnapshotDataSource(name string) *v1.TypedLocalObjectReference {
+	group := VolumeSnapshotGroupVersionKind.Group
+	return &v1.TypedLocalObjectReference{
+		APIGroup: &group,
+		Kind:     VolumeSnapshotGroupVersionKind.Kind,
+		Name:     name,
+	}
+}
+
+// ValidateSnapshotSource checks that the given instance can be provisioned
+// from the given PostgresSnapshot
+func ValidateSnapshotSource(ps *v1alpha1.Postgres, snap *v1alpha1.PostgresSnapshot) error {
+	if IsReplicated(ps) {
+		return errors.New(errSnapshotReplicas)
+	}
+	s := snap.Status.AtProvider
+	if s.Namespace != ps.Namespace {
+		return errors.Errorf(errSnapshotNamespace, snap.Name, s.Namespace, ps.Namespace)
+	}
+	if s.RestoreSize == "" {
+		return nil
+	}
+	restore, err := resource.ParseQuantity(s.RestoreSize)
+	if err != nil {
+		return err
+	}
+	size, err := resource.ParseQuantity(ps.Spec.ForProvider.DatabaseSize)
+	if err != nil {
+		return err
+	}
+	if restore.Cmp(size) > 0 {
+		return errors.Errorf(errRestoreSize, s.RestoreSize, snap.Name, ps.Spec.ForProvider.DatabaseSize)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	scramPrefix = "SCRAM-SHA-256$"
+	md5Prefix   = "md5"
+)
+
+// QuoteIdentifier quotes the given name for use as an SQL identifier
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral quotes the given value for use as an SQL string literal
+func QuoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// quoteShell quotes the given value for use as a single argument in a shell
+// command
+func quoteShell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// PsqlCommand returns the shell command running the given SQL statements as
+// the master user in the given database. Every statement runs in its own
+// transaction unless they are wrapped with Transaction, and rows are printed
+// unaligned without headers.
+func PsqlCommand(database string, statements ...string) string {
+	cmd := []string{`psql -U "$POSTGRES_USER" -v ON_ERROR_STOP=1 -tA -d`, quoteShell(database)}
+	for _, s := range statements {
+		cmd = append(cmd, "-c", quoteShell(s))
+	}
+	return strings.Join(cmd, " ")
+}
+
+// Transaction wraps the given statements in a single transaction. psql stops
+// at the first failing statement and the open transaction is rolled back when
+// its session ends, so either all or none of the statements take effect.
+func Transaction(statements ...string) []string {
+	return append(append([]string{"BEGIN"}, statements...), "COMMIT")
+}
+
+// SplitRows splits the output of PsqlCommand into rows of fields
+func SplitRows(out string) [][]string {
+	var rows [][]string
+	for _, l := range strings.Split(strings.TrimSpace(out), "\n") {
+		if l == "" {
+			continue
+		}
+		rows = append(rows, strings.Split(l, "|"))
+	}
+	return rows
+}
+
+// PasswordMatches returns true if the password hash stored for the given role
+// in pg_authid is the hash of the given password. Both md5 and SCRAM-SHA-256
+// hashes are supported.
+func PasswordMatches(stored, role, password string) bool {
+	switch {
+	case strings.HasPrefix(stored, md5Prefix):
+		sum := md5.Sum([]byte(password + role)) //nolint:gosec
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(md5Prefix+hex.EncodeToString(sum[:]))) == 1
+	case strings.HasPrefix(stored, scramPrefix):
+		return scramMatches(strings.TrimPrefix(stored, scramPrefix), password)
+	default:
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+}
+
+// scramMatches verifies a password against a SCRAM-SHA-256 verifier of the
+// form <iterations>:<salt>$<StoredKey>:<ServerKey>
+func scramMatches(verifier, password string) bool {
+	parts := strings.Split(verifier, "$")
+	if len(parts) != 2 {
+		return false
+	}
+	params, keys := strings.Split(parts[0], ":"), strings.Split(parts[1], ":")
+	if len(params) != 2 || len(keys) != 2 {
+		return false
+	}
+	iterations, err := strconv.Atoi(params[0])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(params[1])
+	if err != nil {
+		return false
+	}
+	storedKey, err := base64.StdEncoding.DecodeString(keys[0])
+	if err != nil {
+		return false
+	}
+	saltedPassword := pbkdf2.Key([]byte(password), salt, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+	sum := sha256.Sum256(clientKey)
+	return hmac.Equal(sum[:], storedKey)
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data) //nolint:errcheck
+	return h.Sum(nil)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"testing"
+)
+
+const (
+	roleName      = "app"
+	userPass      = "password"
+	generatedPass = "123asdf"
+	// scramPass is the SCRAM-SHA-256 verifier of userPass
+	scramPass = "SCRAM-SHA-256$4096:MDEyMzQ1Njc4OWFiY2RlZg==$wjGCKoCIcEWiPxSG7t/wnb/YICMEFr1JZNZSKNje12g=:6NG/vkzOjK2oyl11qeNEBeKuOY3QQ4atswXYbIBO79Q="
+	// md5Pass is the md5 hash of userPass for roleName
+	md5Pass = "md598b0eddb1d41e30a28e098217145c424"
+)
+
+func TestPasswordMatches(t *testing.T) {
+	cases := map[string]struct {
+		stored   string
+		password string
+		want     bool
+	}{
+		"SCRAM":          {stored: scramPass, password: userPass, want: true},
+		"SCRAMMismatch":  {stored: scramPass, password: generatedPass, want: false},
+		"MD5":            {stored: md5Pass, password: userPass, want: true},
+		"MD5Mismatch":    {stored: md5Pass, password: generatedPass, want: false},
+		"InvalidVerfier": {stored: "SCRAM-SHA-256$4096", password: userPass, want: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := PasswordMatches(tc.stored, roleName, tc.password); got != tc.want {
+				t.Errorf("PasswordMatches(...): want %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
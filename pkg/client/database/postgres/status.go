@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	// ServerVersionQuery returns the version of the running server
+	ServerVersionQuery = "SHOW server_version"
+
+	// RolloutComplete means all pods run the current template
+	RolloutComplete = "Complete"
+	// RolloutProgressing means pods are still replaced
+	RolloutProgressing = "Progressing"
+	// RolloutFailed means the rollout exceeded its progress deadline
+	RolloutFailed = "Failed"
+
+	reasonNewReplicaSetAvailable = "NewReplicaSetAvailable"
+	reasonProgressDeadline       = "ProgressDeadlineExceeded"
+)
+
+// ClaimNameForPod returns the name of the PVC the StatefulSet created for
+// the pod with the given name
+func ClaimNameForPod(pod string) string {
+	return dataVolumeName + "-" + pod
+}
+
+// SetStorageStatus records the phase, capacity and StorageClass of the given
+// PVC in the status of the instance
+func SetStorageStatus(ps *v1alpha1.Postgres, pvc *v1.PersistentVolumeClaim) {
+	s := &ps.Status.AtProvider
+	s.PVCStatus = string(pvc.Status.Phase)
+	s.Capacity = ""
+	if q, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+		s.Capacity = q.String()
+	}
+	s.StorageClass = utils.StringValue(pvc.Spec.StorageClassName)
+}
+
+// ObservedPod returns the pod whose state is reported for the instance. That
+// is the named pod if a name is given, otherwise a running pod is preferred
+// over one which is not ready or terminating.
+func ObservedPod(pods []v1.Pod, name string) *v1.Pod {
+	var found *v1.Pod
+	for i := range pods {
+		p := &pods[i]
+		switch {
+		case name != "":
+			if p.Name == name {
+				return p
+			}
+		case p.DeletionTimestamp == nil && IsPodReady(p):
+			return p
+		case found == nil || found.DeletionTimestamp != nil:
+			found = p
+		}
+	}
+	return found
+}
+
+// SetPodStatus records the phase, restarts, node and image of the given pod
+// of the instance in its status. A nil pod clears them.
+func SetPodStatus(ps *v1alpha1.Postgres, pod *v1.Pod) {
+	s := &ps.Status.AtProvider
+	s.PodPhase, s.Restarts, s.NodeName, s.Image = "", 0, "", ""
+	if pod == nil {
+		return
+	}
+	s.PodPhase = string(pod.Status.Phase)
+	s.NodeName = pod.Spec.NodeName
+	for _, c := range pod.Status.ContainerStatuses {
+		s.Restarts += c.RestartCount
+		if c.Name == ps.Name {
+			s.Image = c.Image
+		}
+	}
+	if s.Image != "" {
+		return
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == ps.Name {
+			s.Image = c.Image
+		}
+	}
+}
+
+// DeploymentRollout returns the state of the rollout of the given Deployment
+func DeploymentRollout(dpl *appsv1.Deployment) string {
+	if dpl.Status.ObservedGeneration < dpl.Generation {
+		return RolloutProgressing
+	}
+	for _, c := range dpl.Status.Conditions {
+		if c.Type != appsv1.DeploymentProgressing {
+			continue
+		}
+		switch c.Reason {
+		case reasonProgressDeadline:
+			return RolloutFailed
+		case reasonNewReplicaSetAvailable:
+			if dpl.Status.UpdatedReplicas == dpl.Status.Replicas {
+				return RolloutComplete
+			}
+		}
+	}
+	return RolloutProgressing
+}
+
+// StatefulSetRollout returns the state of the rollout of the given
+// StatefulSet. StatefulSets have no progress deadline, so a rollout never
+// fails.
+func StatefulSetRollout(sts *appsv1.StatefulSet) string {
+	if sts.Status.ObservedGeneration < sts.Generation ||
+		sts.Status.UpdateRevision != sts.Status.CurrentRevision ||
+		sts.Status.UpdatedReplicas != utils.Int32Value(sts.Spec.Replicas) {
+		return RolloutProgressing
+	}
+	return RolloutComplete
+}
+
+// ParseServerVersion returns the version from the output of
+// ServerVersionQuery, e.g. 13.0 for "13.0 (Debian 13.0-1.pgdg100+1)"
+func ParseServerVersion(out string) string {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+)
+
+const (
+	// TLSCAKey is the key of the CA certificate in the TLS Secret of an
+	// instance
+	TLSCAKey = "ca.crt"
+	// HBAFileKey is the key of pg_hba.conf in the config ConfigMap
+	HBAFileKey = "pg_hba.conf"
+	// AnnotationCertificate records the expiry of the certificate a pod was
+	// started with
+	AnnotationCertificate = "in-cluster.crossplane.io/certificate-not-after"
+
+	errNoCertificate     = "no PEM encoded certificate in key %s"
+	errParseCertificate  = "cannot parse certificate"
+	errNoTLSKey          = "secret %s has no key %s"
+	errGenerateKey       = "cannot generate private key"
+	errCreateCertificate = "cannot create certificate"
+
+	tlsCAKeyKey         = "ca.key"
+	tlsSecretVolumeName = "tls-secret"
+	tlsSecretMountPath  = "/etc/postgresql-tls-secret"
+	tlsVolumeName       = "tls"
+	tlsMountPath        = "/etc/postgresql-tls"
+	hbaFilePath         = configMountPath + "/" + HBAFileKey
+
+	caValidity          = 10 * 365 * 24 * time.Hour
+	certificateValidity = 365 * 24 * time.Hour
+	defaultRenewBefore  = 30 * 24 * time.Hour
+)
+
+// tlsConfig enables TLS in postgresql.conf. The connections allowed are
+// configured in the pg_hba.conf rendered by the provider.
+var tlsConfig = fmt.Sprintf(`ssl = on
+ssl_cert_file = '%[1]s/%[2]s'
+ssl_key_file = '%[1]s/%[3]s'
+hba_file = '%[4]s'
+`, tlsMountPath, v1.TLSCertKey, v1.TLSPrivateKeyKey, hbaFilePath)
+
+// hbaTemplate is the pg_hba.conf of an instance with TLS. Local connections
+// are trusted like in the official image, remote ones need a password and,
+// if TLS is enforced, an encrypted connection.
+const hbaTemplate = `# managed by provider-in-cluster, changes are overwritten
+local all all trust
+host all all 127.0.0.1/32 trust
+host all all ::1/128 trust
+local replication all trust
+host replication all 127.0.0.1/32 trust
+host replication all ::1/128 trust
+%[1]s replication all all md5
+%[1]s all all all md5
+`
+
+// tlsInitScript copies the certificate into a volume the postgres user owns,
+// as Postgres refuses to read a key other users can access.
+var tlsInitScript = fmt.Sprintf(`set -e
+cp %[1]s/%[3]s %[1]s/%[4]s %[2]s/
+chown postgres:postgres %[2]s/%[3]s %[2]s/%[4]s
+chmod 0600 %[2]s/%[4]s
+`, tlsSecretMountPath, tlsMountPath, v1.TLSCertKey, v1.TLSPrivateKeyKey)
+
+// IsTLSGenerated returns true if the provider generates the certificate of
+// the given instance
+func IsTLSGenerated(ps *v1alpha1.Postgres) bool {
+	return ps.Spec.ForProvider.TLS != nil && ps.Spec.ForProvider.TLS.SecretName == nil
+}
+
+// IsTLSEnforced returns true if the given instance rejects unencrypted
+// connections
+func IsTLSEnforced(ps *v1alpha1.Postgres) bool {
+	tls := ps.Spec.ForProvider.TLS
+	return tls != nil && tls.Enforce != nil && *tls.Enforce
+}
+
+// GeneratedTLSSecretName returns the name of the Secret holding the
+// certificate the provider generates for the instance
+func GeneratedTLSSecretName(ps *v1alpha1.Postgres) string {
+	return ps.Name + "-tls"
+}
+
+// TLSSecretName returns the name of the Secret holding the certificate the
+// instance serves TLS connections with
+func TLSSecretName(ps *v1alpha1.Postgres) string {
+	if tls := ps.Spec.ForProvider.TLS; tls != nil && tls.SecretName != nil {
+		return *tls.SecretName
+	}
+	return GeneratedTLSSecretName(ps)
+}
+
+// RenewBefore returns how long before its expiry a generated certificate is
+// renewed
+func RenewBefore(ps *v1alpha1.Postgres) time.Duration {
+	if tls := ps.Spec.ForProvider.TLS; tls != nil && tls.RenewBefore != nil && tls.RenewBefore.Duration > 0 {
+		return tls.RenewBefore.Duration
+	}
+	return defaultRenewBefore
+}
+
+// ServerNames returns the DNS names of the Services of the given instance a
+// generated certificate is valid for
+func ServerNames(ps *v1alpha1.Postgres) []string {
+	services := []string{ps.Name}
+	if IsReplicated(ps) {
+		services = append(services, ReadOnlyServiceName(ps))
+	}
+	var names []string
+	for _, svc := range services {
+		names = append(names, svc, svc+"."+ps.Namespace, ServiceHost(svc, ps.Namespace))
+	}
+	return names
+}
+
+// ParseCertificate parses the PEM encoded certificate under the given key of
+// a Secret
+func ParseCertificate(s *v1.Secret, key string) (*x509.Certificate, error) {
+	data, ok := s.Data[key]
+	if !ok {
+		return nil, errors.Errorf(errNoTLSKey, s.Name, key)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.Errorf(errNoCertificate, key)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	return cert, errors.Wrap(err, errParseCertificate)
+}
+
+// IsCertificateDue returns true if the generated certificate in the given
+// Secret has to be renewed, because it is missing, expires within RenewBefore
+// or is not valid for all of the current ServerNames. Additional names, e.g.
+// of a read-only Service which was removed, do not renew it.
+func IsCertificateDue(ps *v1alpha1.Postgres, s *v1.Secret, now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	if _, ok := s.Data[v1.TLSPrivateKeyKey]; !ok {
+		return true
+	}
+	cert, err := ParseCertificate(s, v1.TLSCertKey)
+	if err != nil || !now.Add(RenewBefore(ps)).Before(cert.NotAfter) {
+		return true
+	}
+	names := make(map[string]bool, len(cert.DNSNames))
+	for _, name := range cert.DNSNames {
+		names[name] = true
+	}
+	for _, name := range ServerNames(ps) {
+		if !names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeTLSSecret generates a server certificate for the given instance and
+// returns the Secret holding it. The CA in the current Secret is kept unless
+// it expires within RenewBefore, so that clients keep trusting the instance
+// when its certificate is renewed.
+func MakeTLSSecret(ps *v1alpha1.Postgres, current *v1.Secret, now time.Time) (*v1.Secret, error) {
+	caCert, caKey := currentCA(ps, current, now)
+	if caCert == nil {
+		var err error
+		if caCert, caKey, err = generateCA(ps, now); err != nil {
+			return nil, err
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, errGenerateKey)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	names := ServerNames(ps)
+	tpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: ps.Name + "." + ps.Namespace + ".svc"},
+		DNSNames:     names,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, errCreateCertificate)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, errGenerateKey)
+	}
+	caKeyDER, err := x509.MarshalECPrivateKey(caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, errGenerateKey)
+	}
+	return &v1.Secret{
+		ObjectMeta: objectMeta(ps, GeneratedTLSSecretName(ps)),
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			v1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+			TLSCAKey:            pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}),
+			tlsCAKeyKey:         pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: caKeyDER}),
+		},
+	}, nil
+}
+
+// currentCA returns the CA of the given Secret if it can still sign a
+// certificate, or nil
+func currentCA(ps *v1alpha1.Postgres, s *v1.Secret, now time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+	if s == nil {
+		return nil, nil
+	}
+	cert, err := ParseCertificate(s, TLSCAKey)
+	if err != nil || !now.Add(RenewBefore(ps)).Before(cert.NotAfter) {
+		return nil, nil
+	}
+	block, _ := pem.Decode(s.Data[tlsCAKeyKey])
+	if block == nil {
+		return nil, nil
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil
+	}
+	return cert, key
+}
+
+// generateCA creates the self-signed CA the certificates of the given
+// instance are issued by
+func generateCA(ps *v1alpha1.Postgres, now time.Time) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errGenerateKey)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: ps.Name + " CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errCreateCertificate)
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, errors.Wrap(err, errParseCertificate)
+}
+
+// serialNumber returns a random serial number of 128 bits
+func serialNumber() (*big.Int, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return n, errors.Wrap(err, errCreateCertificate)
+}
+
+// MakeHBAConfig renders the pg_hba.conf of an instance with TLS
+func MakeHBAConfig(ps *v1alpha1.Postgres) string {
+	conn := "host"
+	if IsTLSEnforced(ps) {
+		conn = "hostssl"
+	}
+	return fmt.Sprintf(hbaTemplate, conn)
+}
+
+// addTLS mounts the certificate of an instance with TLS into the given pod
+// template. The expiry of the certificate is recorded on the pods, so that a
+// renewed certificate rolls them.
+func addTLS(ps *v1alpha1.Postgres, tpl *v1.PodTemplateSpec) {
+	if ps.Spec.ForProvider.TLS == nil {
+		return
+	}
+	mount := v1.VolumeMount{Name: tlsVolumeName, MountPath: tlsMountPath}
+	tpl.Spec.Volumes = append(tpl.Spec.Volumes,
+		v1.Volume{
+			Name: tlsSecretVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: TLSSecretName(ps)},
+			},
+		},
+		v1.Volume{
+			Name: tlsVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory},
+			},
+		})
+	tpl.Spec.InitContainers = append(tpl.Spec.InitContainers, v1.Container{
+		Name:    "tls",
+		Image:   Image(ps),
+		Command: []string{"/bin/bash", "-c", tlsInitScript},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: tlsSecretVolumeName, MountPath: tlsSecretMountPath, ReadOnly: true},
+			mount,
+		},
+		ImagePullPolicy: v1.PullIfNotPresent,
+	})
+	mount.ReadOnly = true
+	tpl.Spec.Containers[0].VolumeMounts = append(tpl.Spec.Containers[0].VolumeMounts, mount)
+	if t := ps.Status.AtProvider.CertificateNotAfter; t != nil {
+		tpl.Annotations[AnnotationCertificate] = t.UTC().Format(time.RFC3339)
+	}
+}
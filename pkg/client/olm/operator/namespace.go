@@ -0,0 +1,42 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"strings"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/operator/v1alpha1"
+)
+
+// DefaultNamespace is the namespace operators are subscribed in if none is set
+const DefaultNamespace = "default"
+
+// Namespace returns the namespace in the target cluster the given operator is
+// subscribed in
+func Namespace(op *v1alpha1.Operator) string {
+	if op.Spec.ForProvider.Namespace == nil || strings.TrimSpace(*op.Spec.ForProvider.Namespace) == "" {
+		return DefaultNamespace
+	}
+	return *op.Spec.ForProvider.Namespace
+}
+
+// Conflicts returns true if the given operators subscribe to the same package
+// in the same namespace, which OLM does not support
+func Conflicts(a, b *v1alpha1.Operator) bool {
+	return a.Name != b.Name && Namespace(a) == Namespace(b) &&
+		a.Spec.ForProvider.OperatorName == b.Spec.ForProvider.OperatorName
+}
@@ -80,6 +80,7 @@ func (o operatorClient) CreateOperator(ctx context.Context, op *v1alpha1.Operato
 	}
 	sub.Namespace = op.Namespace
 	sub.Name = op.Name
+	clients.SetOwner(&sub, op, v1alpha1.OperatorGroupVersionKind)
 	return o.kube.Create(ctx, &sub)
 }
 
@@ -129,5 +130,9 @@ func (o operatorClient) DeleteSubscription(ctx context.Context, op *v1alpha1.Ope
 	if err != nil {
 		return err
 	}
+	// a Subscription the operator does not own is left alone
+	if !clients.IsOwnedBy(&cluster, op) {
+		return nil
+	}
 	return o.kube.Delete(ctx, &cluster)
 }
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// LabelOwnerUID is the UID of the managed resource an object was created
+	// for
+	LabelOwnerUID = "in-cluster.crossplane.io/owner-uid"
+	// LabelOwnerKind is the kind of the managed resource an object was
+	// created for
+	LabelOwnerKind = "in-cluster.crossplane.io/owner-kind"
+	// AnnotationOwnerAPIVersion is the API version of the managed resource an
+	// object was created for
+	AnnotationOwnerAPIVersion = "in-cluster.crossplane.io/owner-api-version"
+	// AnnotationOwnerName is the name of the managed resource an object was
+	// created for
+	AnnotationOwnerName = "in-cluster.crossplane.io/owner-name"
+	// AnnotationAdopt set to "true" on an existing object lets a managed
+	// resource take it over although it does not own it
+	AnnotationAdopt = "in-cluster.crossplane.io/adopt"
+
+	errNotOwned = "%s %s/%s is not owned by %s %s, annotate it with %s=true to adopt it"
+)
+
+// SetOwner labels and annotates the given object with the kind, name and UID
+// of the managed resource it is created for
+func SetOwner(obj, owner metav1.Object, gvk schema.GroupVersionKind) {
+	obj.SetLabels(mergeStringMap(obj.GetLabels(), map[string]string{
+		LabelOwnerUID:  string(owner.GetUID()),
+		LabelOwnerKind: gvk.Kind,
+	}))
+	obj.SetAnnotations(mergeStringMap(obj.GetAnnotations(), map[string]string{
+		AnnotationOwnerAPIVersion: gvk.GroupVersion().String(),
+		AnnotationOwnerName:       owner.GetName(),
+	}))
+}
+
+// CopyOwner copies the owner labels and annotations of from onto to, leaving
+// its other labels and annotations alone
+func CopyOwner(from, to metav1.Object) {
+	labels, annotations := map[string]string{}, map[string]string{}
+	for _, k := range []string{LabelOwnerUID, LabelOwnerKind} {
+		if v, ok := from.GetLabels()[k]; ok {
+			labels[k] = v
+		}
+	}
+	for _, k := range []string{AnnotationOwnerAPIVersion, AnnotationOwnerName} {
+		if v, ok := from.GetAnnotations()[k]; ok {
+			annotations[k] = v
+		}
+	}
+	to.SetLabels(mergeStringMap(to.GetLabels(), labels))
+	to.SetAnnotations(mergeStringMap(to.GetAnnotations(), annotations))
+}
+
+// CheckAdoption returns an error if the existing object current is not owned
+// by the managed resource the desired object is created for, unless current
+// is annotated for adoption. Objects which do not exist yet, i.e. have no
+// resource version, can always be created.
+func CheckAdoption(current, desired metav1.Object) error {
+	if current.GetResourceVersion() == "" ||
+		current.GetLabels()[LabelOwnerUID] == desired.GetLabels()[LabelOwnerUID] ||
+		current.GetAnnotations()[AnnotationAdopt] == "true" {
+		return nil
+	}
+	kind := reflect.Indirect(reflect.ValueOf(current)).Type().Name()
+	if u, ok := current.(interface{ GetKind() string }); ok && u.GetKind() != "" {
+		kind = u.GetKind()
+	}
+	return errors.Errorf(errNotOwned, kind, current.GetNamespace(), current.GetName(),
+		desired.GetLabels()[LabelOwnerKind], desired.GetAnnotations()[AnnotationOwnerName], AnnotationAdopt)
+}
+
+// ObserveOwner checks whether the existing object current may be managed for
+// the managed resource the desired object is created for, see CheckAdoption.
+// It returns false while an adopted object is not labelled with its owner.
+func ObserveOwner(current, desired metav1.Object) (bool, error) {
+	if err := CheckAdoption(current, desired); err != nil {
+		return false, err
+	}
+	return current.GetLabels()[LabelOwnerUID] == desired.GetLabels()[LabelOwnerUID], nil
+}
+
+// IsOwnedBy returns true if the existing object was created for the given
+// managed resource or is annotated for adoption
+func IsOwnedBy(obj, owner metav1.Object) bool {
+	return obj.GetLabels()[LabelOwnerUID] == string(owner.GetUID()) ||
+		obj.GetAnnotations()[AnnotationAdopt] == "true"
+}
+
+func mergeStringMap(current, desired map[string]string) map[string]string {
+	if len(desired) == 0 {
+		return current
+	}
+	if current == nil {
+		current = make(map[string]string, len(desired))
+	}
+	for k, v := range desired {
+		current[k] = v
+	}
+	return current
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errClassMsg       = "failed to get postgres class"                   //nolint:golint
+	errClassUpdateMsg = "failed to store the defaults of postgres class" //nolint:golint
+	errNoDatabaseSize = "databaseSize has to be set on the instance or its class"
+)
+
+// classInitializer copies the values of the PostgresClass of an instance into
+// its unset parameters. The instance is annotated with the class, so that the
+// class is only fetched once and later changes of it do not affect existing
+// instances. It runs as an initializer of the managed reconciler, which stores
+// the spec before the instance is observed rather than concurrently with the
+// status stored after Observe.
+type classInitializer struct {
+	kube client.Client
+}
+
+// Initialize applies the class of the given instance
+func (c *classInitializer) Initialize(ctx context.Context, mg resource.Managed) error {
+	ps, ok := mg.(*v1alpha1.Postgres)
+	if !ok {
+		return errors.New(errUnexpectedObject)
+	}
+	name := ps.Spec.ForProvider.ClassName
+	if meta.WasDeleted(ps) || name == nil || ps.GetAnnotations()[postgres.AnnotationClass] == *name {
+		return nil
+	}
+	class := &v1alpha1.PostgresClass{}
+	// a missing class is tolerated if the instance sets databaseSize, which
+	// is checked by Observe
+	if err := c.kube.Get(ctx, types.NamespacedName{Name: *name}, class); err != nil {
+		return errors.Wrap(resource.IgnoreNotFound(err), errClassMsg)
+	}
+	postgres.ApplyClass(&ps.Spec.ForProvider, class.Spec)
+	meta.AddAnnotations(ps, map[string]string{postgres.AnnotationClass: *name})
+	return errors.Wrap(c.kube.Update(ctx, ps), errClassUpdateMsg)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errConfigMsg       = "failed to get postgres config"              //nolint:golint
+	errConfigCreateMsg = "failed to create or update postgres config" //nolint:golint
+	errReloadConfigMsg = "failed to reload postgres config"           //nolint:golint
+)
+
+// isConfigUpToDate checks whether the config ConfigMap holds the current
+// postgresql.conf and the running instance has loaded it
+func (e *external) isConfigUpToDate(ctx context.Context, ps *v1alpha1.Postgres) (bool, error) {
+	desired := postgres.MakePostgresConfigMap(ps)
+	cm := &v1.ConfigMap{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, cm)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errConfigMsg)
+	}
+	return postgres.IsConfigMapUpToDate(desired, cm) &&
+		ps.Status.AtProvider.ConfigChecksum == postgres.ConfigChecksum(desired), nil
+}
+
+// applyConfig writes the current postgresql.conf to the config ConfigMap
+func (e *external) applyConfig(ctx context.Context, ps *v1alpha1.Postgres) error {
+	_, err := e.client.CreateOrUpdate(ctx, postgres.MakePostgresConfigMap(ps))
+	return errors.Wrap(err, errConfigCreateMsg)
+}
+
+// reloadConfig makes the ready pods of the instance load the current
+// postgresql.conf. Pods which were started with other values of the
+// parameters Postgres only reads at start are reported as pending a restart,
+// they are rolled by their Deployment or StatefulSet. The checksum of the
+// configuration is recorded once the ready pods have loaded it, or there are
+// none which could load it, and no restart is pending. Until then
+// reloadConfig is retried by every Update.
+func (e *external) reloadConfig(ctx context.Context, ps *v1alpha1.Postgres) error {
+	checksum := postgres.ConfigChecksum(postgres.MakePostgresConfigMap(ps))
+	if ps.Status.AtProvider.ConfigChecksum == checksum {
+		return nil
+	}
+	pods, err := e.instancePods(ctx, ps)
+	if err != nil {
+		return err
+	}
+
+	restart := postgres.RestartParameters(ps)
+	pending := map[string]bool{}
+	stale := false
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		for _, name := range postgres.PendingRestart(restart, pod.Annotations[postgres.AnnotationRestartParameters]) {
+			pending[name] = true
+		}
+		// pods which are not ready load the configuration when they start
+		if pod.DeletionTimestamp != nil || !postgres.IsPodReady(pod) {
+			continue
+		}
+		out, err := e.client.ExecInPod(ctx, pod, ps.Name, postgres.ReloadConfigCommand(checksum))
+		if err != nil {
+			return errors.Wrap(err, errReloadConfigMsg)
+		}
+		if postgres.IsConfigStale(out) {
+			stale = true
+		}
+	}
+
+	ps.Status.AtProvider.PendingRestart = nil
+	for name := range pending {
+		ps.Status.AtProvider.PendingRestart = append(ps.Status.AtProvider.PendingRestart, name)
+	}
+	sort.Strings(ps.Status.AtProvider.PendingRestart)
+	// pods which start later read the applied ConfigMap
+	if !stale && len(pending) == 0 {
+		ps.Status.AtProvider.ConfigChecksum = checksum
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+// completeConnectionDetails adds the DNS name of the Service, the URI and
+// the JDBC URL to the given connection details and renders the additional
+// connection details over them. Clients inside the cluster connect through
+// the DNS name, those outside through the published endpoint, so the URLs
+// are left out while the endpoint of a NodePort or LoadBalancer Service is
+// not known yet.
+func completeConnectionDetails(ps *v1alpha1.Postgres, details managed.ConnectionDetails) (managed.ConnectionDetails, error) {
+	host := postgres.ServiceHost(ps.Name, ps.Namespace)
+	details[postgres.ResourceCredentialsSecretHostKey] = []byte(host)
+	switch postgres.ServiceType(ps) {
+	case v1alpha1.ServiceTypeNodePort, v1alpha1.ServiceTypeLoadBalancer:
+		host = string(details[runtimev1alpha1.ResourceCredentialsSecretEndpointKey])
+	}
+	return postgres.CompleteConnectionDetails(ps, details, host, string(details[runtimev1alpha1.ResourceCredentialsSecretPortKey]))
+}
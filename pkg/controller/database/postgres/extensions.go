@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errObserveExtensionsMsg = "failed to observe postgres extensions" //nolint:golint
+	errUpdateExtensionsMsg  = "failed to update postgres extensions"  //nolint:golint
+)
+
+// hasExtensions returns true if the provider manages extensions of the
+// instance, or did so before
+func hasExtensions(ps *v1alpha1.Postgres) bool {
+	return len(ps.Spec.ForProvider.Extensions) > 0 || len(ps.Status.AtProvider.Extensions) > 0
+}
+
+// installedExtensions reads the extensions installed in the database of the
+// instance
+func (e *external) installedExtensions(ctx context.Context, ps *v1alpha1.Postgres) (map[string]string, error) {
+	out, err := e.client.ExecSQL(ctx, ps, utils.StringValue(ps.Spec.ForProvider.Database), postgres.ObserveExtensionsQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, errObserveExtensionsMsg)
+	}
+	installed, err := postgres.ParseExtensions(out)
+	return installed, errors.Wrap(err, errObserveExtensionsMsg)
+}
+
+// observeExtensions records the versions of the extensions installed in the
+// database of the instance and checks whether they match the spec
+func (e *external) observeExtensions(ctx context.Context, ps *v1alpha1.Postgres) (bool, error) {
+	if !hasExtensions(ps) {
+		return true, nil
+	}
+	installed, err := e.installedExtensions(ctx, ps)
+	if err != nil {
+		return false, err
+	}
+	p, s := ps.Spec.ForProvider, &ps.Status.AtProvider
+	stmts := postgres.ExtensionStatements(p.Extensions, s.Extensions, installed)
+	s.Extensions = postgres.ExtensionStatus(p.Extensions, s.Extensions, installed)
+	return len(stmts) == 0, nil
+}
+
+// updateExtensions creates, updates and drops the extensions in the database
+// of the instance. While a change of shared_preload_libraries waits for the
+// instance to restart this is left to a later Update, as the extensions
+// needing the library cannot be created before.
+func (e *external) updateExtensions(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if !hasExtensions(ps) {
+		return nil
+	}
+	for _, name := range ps.Status.AtProvider.PendingRestart {
+		if name == postgres.SharedPreloadLibraries {
+			return nil
+		}
+	}
+	installed, err := e.installedExtensions(ctx, ps)
+	if err != nil {
+		return err
+	}
+	stmts := postgres.ExtensionStatements(ps.Spec.ForProvider.Extensions, ps.Status.AtProvider.Extensions, installed)
+	if len(stmts) == 0 {
+		return nil
+	}
+	_, err = e.client.ExecSQL(ctx, ps, utils.StringValue(ps.Spec.ForProvider.Database), stmts...)
+	return errors.Wrap(err, errUpdateExtensionsMsg)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errPromoteMsg       = "failed to promote postgres standby"         //nolint:golint
+	errDeletePrimaryMsg = "failed to delete pod of the failed primary" //nolint:golint
+	errNoFailoverTarget = "the primary is unhealthy but no standby could report its replay position"
+
+	reasonFailover event.Reason = "FailedOver"
+
+	// failoverGracePeriod is how long the primary may be not ready before a
+	// standby is promoted
+	failoverGracePeriod = time.Minute
+)
+
+// primaryUnhealthy returns true if the pod of the primary has not been ready
+// for longer than the grace period. A missing pod is recreated by the
+// StatefulSet, e.g. during a rolling update, and does not count as unhealthy.
+func primaryUnhealthy(o *replicatedObjects, now time.Time) bool {
+	for i := range o.pods.Items {
+		pod := &o.pods.Items[i]
+		if pod.Name != o.primary {
+			continue
+		}
+		if postgres.IsPodReady(pod) {
+			return false
+		}
+		since := pod.CreationTimestamp.Time
+		for _, c := range pod.Status.Conditions {
+			if c.Type == v1.PodReady {
+				since = c.LastTransitionTime.Time
+			}
+		}
+		return now.Sub(since) > failoverGracePeriod
+	}
+	return false
+}
+
+// failoverCandidates returns the standbys which are ready to take over
+func failoverCandidates(o *replicatedObjects) []*v1.Pod {
+	var pods []*v1.Pod
+	for i := range o.pods.Items {
+		pod := &o.pods.Items[i]
+		if pod.Name != o.primary && pod.DeletionTimestamp == nil && postgres.IsPodReady(pod) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+// needsFailover returns true if the primary is unhealthy and a standby can
+// take over
+func needsFailover(o *replicatedObjects, now time.Time) bool {
+	return primaryUnhealthy(o, now) && len(failoverCandidates(o)) > 0
+}
+
+// failover promotes the standby with the most recent WAL position, points the
+// replication config and the primary Service at it and deletes the pod of the
+// failed primary, which rejoins as standby once the StatefulSet recreates it.
+func (e *external) failover(ctx context.Context, ps *v1alpha1.Postgres, o *replicatedObjects) error {
+	var target *v1.Pod
+	var best uint64
+	for _, pod := range failoverCandidates(o) {
+		out, err := e.client.ExecInPod(ctx, pod, ps.Name, postgres.ReplayPositionCommand)
+		if err != nil {
+			e.logger.Debug("cannot get replay position of standby", "pod", pod.Name, "err", err)
+			continue
+		}
+		pos, err := strconv.ParseUint(strings.TrimSpace(out), 10, 64)
+		if err != nil {
+			e.logger.Debug("cannot parse replay position of standby", "pod", pod.Name, "err", err)
+			continue
+		}
+		if target == nil || pos > best {
+			target, best = pod, pos
+		}
+	}
+	if target == nil {
+		return errors.New(errNoFailoverTarget)
+	}
+
+	if _, err := e.client.ExecInPod(ctx, target, ps.Name, postgres.PromoteCommand); err != nil {
+		return errors.Wrap(err, errPromoteMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeReplicationConfigMap(ps, target.Name)); err != nil {
+		return errors.Wrap(err, errReplicationCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePrimaryPostgresService(ps, target.Name)); err != nil {
+		return errors.Wrap(err, errSVCCreateMsg)
+	}
+	for i := range o.pods.Items {
+		if o.pods.Items[i].Name == o.primary {
+			if err := e.client.DeletePod(ctx, &o.pods.Items[i]); err != nil {
+				return errors.Wrap(err, errDeletePrimaryMsg)
+			}
+		}
+	}
+
+	ps.Status.AtProvider.LastFailover = &v1alpha1.PostgresFailoverStatus{
+		FromPod: o.primary,
+		ToPod:   target.Name,
+		Time:    metav1.Now(),
+	}
+	e.recorder.Event(ps, event.Normal(reasonFailover,
+		fmt.Sprintf("Promoted standby %s after primary %s was not ready for %s", target.Name, o.primary, failoverGracePeriod)))
+	o.primary = target.Name
+	ps.Status.AtProvider.Primary = target.Name
+	return nil
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errMonitoringSecretMsg       = "failed to get postgres monitoring secret"                //nolint:golint
+	errMonitoringSecretCreateMsg = "failed to create or update postgres monitoring secret"   //nolint:golint
+	errObserveMonitoringMsg      = "failed to observe postgres monitoring role"              //nolint:golint
+	errUpdateMonitoringMsg       = "failed to update postgres monitoring role"               //nolint:golint
+	errServiceMonitorMsg         = "failed to get postgres service monitor"                  //nolint:golint
+	errServiceMonitorCreateMsg   = "failed to create or update postgres service monitor"     //nolint:golint
+	errServiceMonitorDeleteMsg   = "failed to delete postgres service monitor"               //nolint:golint
+	errDeleteMonitoringMsg       = "failed to delete postgres monitoring secret and monitor" //nolint:golint
+)
+
+// hasMonitoring returns true if the instance is monitored, or its monitoring
+// role has not been dropped yet
+func hasMonitoring(ps *v1alpha1.Postgres) bool {
+	return postgres.IsMonitored(ps) || ps.Status.AtProvider.MonitoringRole != ""
+}
+
+// monitoringPassword returns the password of the monitoring role kept in the
+// monitoring Secret of the instance and whether the Secret exists
+func (e *external) monitoringPassword(ctx context.Context, ps *v1alpha1.Postgres) (string, bool, error) {
+	s := &v1.Secret{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: postgres.MonitoringSecretName(ps), Namespace: ps.Namespace}, s)
+	if kerrors.IsNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.Wrap(err, errMonitoringSecretMsg)
+	}
+	return string(s.Data[postgres.PasswordSecretKey]), true, nil
+}
+
+// applyMonitoringSecret creates the monitoring Secret of a monitored instance
+// with a generated password unless it exists. The exporter reads the password
+// from the Secret, so it has to exist before the pods of the instance.
+func (e *external) applyMonitoringSecret(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if !postgres.IsMonitored(ps) {
+		return nil
+	}
+	_, stored, err := e.monitoringPassword(ctx, ps)
+	if err != nil || stored {
+		return err
+	}
+	pw, err := e.client.GeneratePassword()
+	if err != nil {
+		return errors.Wrap(err, errGeneratePasswordMsg)
+	}
+	_, err = e.client.CreateOrUpdate(ctx, postgres.MakeMonitoringSecret(ps, pw))
+	return errors.Wrap(err, errMonitoringSecretCreateMsg)
+}
+
+// isMonitoringUpToDate checks that a monitored instance has its monitoring
+// Secret and, if the Prometheus Operator is installed, the ServiceMonitor its
+// spec asks for
+func (e *external) isMonitoringUpToDate(ctx context.Context, ps *v1alpha1.Postgres) (bool, error) {
+	if !postgres.IsMonitored(ps) {
+		return true, nil
+	}
+	_, stored, err := e.monitoringPassword(ctx, ps)
+	if err != nil || !stored {
+		return false, err
+	}
+	sm := newServiceMonitor()
+	err = e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, sm)
+	switch {
+	case meta.IsNoMatchError(err):
+		return true, nil
+	case kerrors.IsNotFound(err):
+		return !postgres.WantsServiceMonitor(ps), nil
+	case err != nil:
+		return false, errors.Wrap(err, errServiceMonitorMsg)
+	}
+	if !postgres.WantsServiceMonitor(ps) {
+		return !clients.IsOwnedBy(sm, ps), nil
+	}
+	desired := postgres.MakeServiceMonitor(ps)
+	owned, err := clients.ObserveOwner(sm, desired)
+	if err != nil {
+		return false, err
+	}
+	return owned && postgres.IsServiceMonitorUpToDate(desired, sm), nil
+}
+
+// applyServiceMonitor creates or deletes the ServiceMonitor of a monitored
+// instance as its spec asks for. Nothing is done if the Prometheus Operator is
+// not installed.
+func (e *external) applyServiceMonitor(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if postgres.WantsServiceMonitor(ps) {
+		_, err := e.client.CreateOrUpdate(ctx, postgres.MakeServiceMonitor(ps))
+		if meta.IsNoMatchError(err) {
+			e.logger.Debug("ServiceMonitor CRD not installed, skipping the service monitor")
+			return nil
+		}
+		return errors.Wrap(err, errServiceMonitorCreateMsg)
+	}
+	sm := newServiceMonitor()
+	if err := e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, sm); err != nil {
+		return errors.Wrap(resource.Ignore(postgres.IsMissing, err), errServiceMonitorMsg)
+	}
+	if !clients.IsOwnedBy(sm, ps) {
+		return nil
+	}
+	return errors.Wrap(resource.Ignore(postgres.IsMissing, e.kube.Delete(ctx, sm)), errServiceMonitorDeleteMsg)
+}
+
+// monitoringStatements returns the statements converging the monitoring role
+// to the spec and records whether it exists
+func (e *external) monitoringStatements(ctx context.Context, ps *v1alpha1.Postgres) ([]string, error) {
+	password, _, err := e.monitoringPassword(ctx, ps)
+	if err != nil {
+		return nil, err
+	}
+	out, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, postgres.ObserveMonitoringRoleQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, errObserveMonitoringMsg)
+	}
+	stmts, exists, err := postgres.MonitoringStatements(ps, out, password)
+	if err != nil {
+		return nil, errors.Wrap(err, errObserveMonitoringMsg)
+	}
+	ps.Status.AtProvider.MonitoringRole = ""
+	if exists {
+		ps.Status.AtProvider.MonitoringRole = postgres.MonitoringRole
+	}
+	return stmts, nil
+}
+
+// observeMonitoringRole checks that the monitoring role exists with the
+// password of the monitoring Secret while the instance is monitored, and is
+// dropped afterwards
+func (e *external) observeMonitoringRole(ctx context.Context, ps *v1alpha1.Postgres) (bool, error) {
+	if !hasMonitoring(ps) {
+		return true, nil
+	}
+	stmts, err := e.monitoringStatements(ctx, ps)
+	return len(stmts) == 0, err
+}
+
+// updateMonitoring converges the ServiceMonitor and the monitoring role of the
+// instance to its spec. Once monitoring is turned off the monitoring Secret
+// and the ServiceMonitor are deleted and the role is dropped.
+func (e *external) updateMonitoring(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if !hasMonitoring(ps) {
+		return nil
+	}
+	if postgres.IsMonitored(ps) {
+		if err := e.applyServiceMonitor(ctx, ps); err != nil {
+			return err
+		}
+	} else if err := e.client.DeletePostgresMonitoring(ctx, ps); err != nil {
+		return errors.Wrap(err, errDeleteMonitoringMsg)
+	}
+	stmts, err := e.monitoringStatements(ctx, ps)
+	if err != nil || len(stmts) == 0 {
+		return err
+	}
+	_, err = e.client.ExecSQL(ctx, ps, maintenanceDatabase, stmts...)
+	return errors.Wrap(err, errUpdateMonitoringMsg)
+}
+
+func newServiceMonitor() *unstructured.Unstructured {
+	sm := &unstructured.Unstructured{}
+	sm.SetGroupVersionKind(postgres.ServiceMonitorGroupVersionKind)
+	return sm
+}
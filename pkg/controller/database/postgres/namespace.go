@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errListPostgresMsg = "failed to list postgres instances" //nolint:golint
+	errNameConflict    = "postgres %s conflicts with postgres %s in namespace %s, the provider would create objects with the same name for both"
+)
+
+// olderConflict returns an instance created before the given one that is
+// placed in the same namespace of the same cluster, i.e. uses the same
+// provider config, and for which the provider would create objects with the
+// same name. The instance created first keeps its objects.
+func (e *external) olderConflict(ctx context.Context, ps *v1alpha1.Postgres) (*v1alpha1.Postgres, error) {
+	l := &v1alpha1.PostgresList{}
+	if err := e.local.List(ctx, l); err != nil {
+		return nil, errors.Wrap(err, errListPostgresMsg)
+	}
+	for i := range l.Items {
+		other := &l.Items[i]
+		if clients.SameProviderConfig(ps, other) && postgres.Conflicts(ps, other) && clients.CreatedBefore(other, ps) {
+			return other, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkConflicts returns an error if an instance created before the given one
+// conflicts with it
+func (e *external) checkConflicts(ctx context.Context, ps *v1alpha1.Postgres) error {
+	other, err := e.olderConflict(ctx, ps)
+	if err != nil || other == nil {
+		return err
+	}
+	return errors.Errorf(errNameConflict, ps.Name, other.Name, postgres.Namespace(ps))
+}
+
+// isNamespaceUpToDate checks whether the namespace of the instance carries
+// its namespace labels, if the provider creates the namespace
+func (e *external) isNamespaceUpToDate(ctx context.Context, ps *v1alpha1.Postgres) (bool, error) {
+	if !utils.BoolValueFallback(ps.Spec.ForProvider.CreateNamespace, false) {
+		return true, nil
+	}
+	return clients.IsNamespaceUpToDate(ctx, e.kube, ps.Namespace, ps.Spec.ForProvider.NamespaceLabels)
+}
+
+// applyNamespace creates the namespace of the instance with its namespace
+// labels, if the provider creates the namespace
+func (e *external) applyNamespace(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if !utils.BoolValueFallback(ps.Spec.ForProvider.CreateNamespace, false) {
+		return nil
+	}
+	return clients.EnsureNamespace(ctx, e.kube, ps.Namespace, ps.Spec.ForProvider.NamespaceLabels)
+}
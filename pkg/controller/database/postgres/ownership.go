@@ -0,0 +1,45 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+)
+
+// owned pairs an observed object of an instance with the desired state the
+// provider renders for it
+type owned struct {
+	observed metav1.Object
+	desired  metav1.Object
+}
+
+// observeOwners returns an error if one of the observed objects is owned by
+// someone else and not annotated for adoption, and false while an adopted
+// object is not labelled with its owner yet
+func observeOwners(objs ...owned) (bool, error) {
+	upToDate := true
+	for _, o := range objs {
+		ok, err := clients.ObserveOwner(o.observed, o.desired)
+		if err != nil {
+			return false, err
+		}
+		upToDate = upToDate && ok
+	}
+	return upToDate, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errPasswordSecretMsg       = "failed to get master password secret"                //nolint:golint
+	errRotatePasswordMsg       = "failed to rotate postgres master password"           //nolint:golint
+	errInstanceSecretMsg       = "failed to get postgres password secret"              //nolint:golint
+	errInstanceSecretCreateMsg = "failed to create or update postgres password secret" //nolint:golint
+	errRestartStandbyMsg       = "failed to restart postgres standby"                  //nolint:golint
+
+	reasonPasswordRotated event.Reason = "RotatedPassword"
+
+	// maintenanceDatabase is connected to when altering the master user
+	maintenanceDatabase = "postgres"
+)
+
+// currentPassword returns the master password the instance runs with and
+// whether it is kept in the password Secret of the instance. Instances created
+// before the Secret was introduced carry the password in their pod template.
+func (e *external) currentPassword(ctx context.Context, ps *v1alpha1.Postgres, tpl v1.PodTemplateSpec) (string, bool, error) {
+	s := &v1.Secret{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: postgres.PasswordSecretName(ps), Namespace: ps.Namespace}, s)
+	if kerrors.IsNotFound(err) {
+		return postgres.PasswordFromPodTemplate(tpl), false, nil
+	}
+	if err != nil {
+		return "", false, errors.Wrap(err, errInstanceSecretMsg)
+	}
+	return string(s.Data[postgres.PasswordSecretKey]), true, nil
+}
+
+// initialPassword returns the master password a new instance is created with.
+// A generated password is read back from the password Secret when Create is
+// retried, so that the instance and its connection details never diverge.
+func (e *external) initialPassword(ctx context.Context, ps *v1alpha1.Postgres) (string, error) {
+	if pw, err := e.client.ParseInputSecret(ctx, *ps); err == nil && pw != "" {
+		return pw, nil
+	}
+	pw, stored, err := e.currentPassword(ctx, ps, v1.PodTemplateSpec{})
+	if err != nil || (stored && pw != "") {
+		return pw, err
+	}
+	pw, err = e.client.GeneratePassword()
+	return pw, errors.Wrap(err, errGeneratePasswordMsg)
+}
+
+// storePassword moves the password of an instance created before the password
+// Secret into the Secret and rotates it if due. It returns the password the
+// instance runs with afterwards and whether it was rotated.
+func (e *external) storePassword(ctx context.Context, ps *v1alpha1.Postgres, tpl v1.PodTemplateSpec) (string, bool, error) {
+	password, stored, err := e.currentPassword(ctx, ps, tpl)
+	if err != nil {
+		return "", false, err
+	}
+	if !stored {
+		if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePasswordSecret(ps, password)); err != nil {
+			return "", false, errors.Wrap(err, errInstanceSecretCreateMsg)
+		}
+	}
+	return e.rotatePassword(ctx, ps, password)
+}
+
+// passwordRotation checks whether the master password the instance currently
+// runs with has to be rotated. A referenced password is returned as soon as
+// it differs from the current one, a generated password is due once the
+// rotation interval has passed since the last rotation.
+func (e *external) passwordRotation(ctx context.Context, ps *v1alpha1.Postgres, current string, now time.Time) (string, bool, error) {
+	if ps.Spec.ForProvider.MasterPasswordSecretRef != nil {
+		pw, err := e.client.ParseInputSecret(ctx, *ps)
+		if err != nil {
+			return "", false, errors.Wrap(err, errPasswordSecretMsg)
+		}
+		return pw, pw != "" && pw != current, nil
+	}
+	interval, last := ps.Spec.ForProvider.RotationInterval, ps.Status.AtProvider.LastPasswordRotation
+	if interval == nil || interval.Duration <= 0 {
+		return "", false, nil
+	}
+	if last == nil {
+		// instances created before the interval was set start counting now
+		ps.Status.AtProvider.LastPasswordRotation = &metav1.Time{Time: now}
+		return "", false, nil
+	}
+	return "", !now.Before(last.Add(interval.Duration)), nil
+}
+
+// rotatePassword sets a new master password inside the running instance if a
+// rotation is due, stores it in the password Secret and returns the password
+// the instance runs with afterwards. The rotation is only recorded once the
+// password is stored, so that a failed attempt is repeated. The caller has to
+// republish the connection details.
+func (e *external) rotatePassword(ctx context.Context, ps *v1alpha1.Postgres, current string) (string, bool, error) {
+	pw, due, err := e.passwordRotation(ctx, ps, current, time.Now())
+	if err != nil || !due {
+		return current, false, err
+	}
+	if pw == "" {
+		if pw, err = e.client.GeneratePassword(); err != nil {
+			return current, false, errors.Wrap(err, errGeneratePasswordMsg)
+		}
+	}
+	stmt := fmt.Sprintf("ALTER USER %s WITH PASSWORD %s",
+		postgres.QuoteIdentifier(postgres.MasterUsername(ps)), postgres.QuoteLiteral(pw))
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, stmt); err != nil {
+		return current, false, errors.Wrap(err, errRotatePasswordMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePasswordSecret(ps, pw)); err != nil {
+		return current, false, errors.Wrap(err, errInstanceSecretCreateMsg)
+	}
+	ps.Status.AtProvider.LastPasswordRotation = &metav1.Time{Time: time.Now()}
+	e.recorder.Event(ps, event.Normal(reasonPasswordRotated, "Rotated the master password"))
+	return pw, true, nil
+}
@@ -18,25 +18,29 @@ package postgres
 
 import (
 	"context"
-	"fmt"
 	"strconv"
-	"strings"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/apis/v1beta1"
 	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
 	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
 	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
@@ -47,34 +51,42 @@ const (
 	errDelete              = "failed to delete the Postgres resource"          //nolint:golint
 	errDeploymentMsg       = "failed to get postgres deployment"               //nolint:golint
 	errServiceMsg          = "failed to get postgres service"                  //nolint:golint
+	errPVCMsg              = "failed to get postgres PVC"                      //nolint:golint
 	errPVCCreateMsg        = "failed to create or update postgres PVC"         //nolint:golint
 	errDeployCreateMsg     = "failed to create or update postgres deployment"  //nolint:golint
 	errSVCCreateMsg        = "failed to create or update postgres service"     //nolint:golint
 	errGeneratePasswordMsg = "failed to generate potential postgres password"  //nolint:golint
-
-	// ResourceCredentialsSecretDatabaseKey is the key for the connection secret database
-	ResourceCredentialsSecretDatabaseKey = "database"
+	errStorageClassMsg     = "failed to get storage class of postgres PVC"     //nolint:golint
+	errShrinkPVC           = "cannot shrink the database from %s to %s, the databaseSize can only be increased"
+	errExpansionDisabled   = "cannot resize the database, storage class %q does not allow volume expansion"
+	errDowngrade           = "cannot downgrade postgres from version %s to %s"
 )
 
 // SetupPostgres adds a controller that reconciles Postgres instances.
 func SetupPostgres(mgr ctrl.Manager, l logging.Logger) error {
 	name := managed.ControllerName(v1alpha1.PostgresGroupKind)
 	postgresLogger := l.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		For(&v1alpha1.Postgres{}).
 		Complete(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha1.PostgresGroupVersionKind),
-			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: postgresLogger}),
+			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: postgresLogger, recorder: recorder}),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithInitializers(
+				managed.NewDefaultProviderConfig(mgr.GetClient()),
+				managed.NewNameAsExternalName(mgr.GetClient()),
+				&classInitializer{kube: mgr.GetClient()}),
 			managed.WithLogger(postgresLogger),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))
 }
 
 type connector struct {
 	kube        client.Client
-	newClientFn func(kube client.Client) postgres.Client
+	newClientFn func(kube client.Client, cs kubernetes.Interface, rc *rest.Config) postgres.Client
 	logger      logging.Logger
+	recorder    event.Recorder
 }
 
 func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
@@ -85,7 +97,12 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 
 	c.logger.Debug("Connecting")
 
-	rc, err := clients.GetProviderConfigRC(ctx, cr, c.kube)
+	pc, err := clients.GetProviderConfig(ctx, cr, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := clients.ProviderConfigRC(ctx, pc, c.kube)
 	if err != nil {
 		return nil, err
 	}
@@ -100,14 +117,20 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, err
 	}
 
-	return &external{client: c.newClientFn(kube), kube: kube, logger: c.logger, cs: cs}, nil
+	return &external{client: c.newClientFn(kube, cs, rc), kube: kube, local: c.kube, logger: c.logger, recorder: c.recorder, cs: cs, podSecurity: clients.PodSecurityProfile(pc)}, nil
 }
 
+// external manages the objects of an instance. kube is the client of the
+// cluster the instance runs in, local the client of the cluster the managed
+// resources live in.
 type external struct {
-	client postgres.Client
-	kube   client.Client
-	cs     kubernetes.Interface
-	logger logging.Logger
+	client      postgres.Client
+	kube        client.Client
+	local       client.Client
+	cs          kubernetes.Interface
+	logger      logging.Logger
+	recorder    event.Recorder
+	podSecurity v1beta1.PodSecurityProfile
 }
 
 func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.ExternalObservation, error) {
@@ -116,16 +139,119 @@ func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.E
 		return managed.ExternalObservation{}, errors.New(errUnexpectedObject)
 	}
 
+	// the class of the instance has been applied by classInitializer
+	if !meta.WasDeleted(ps) && ps.Spec.ForProvider.DatabaseSize == "" {
+		return managed.ExternalObservation{}, errors.New(errNoDatabaseSize)
+	}
 	// set initial default values
 	initializeDefaults(ps)
+	// the pods are rendered with the security profile of the provider config
+	ps.Status.AtProvider.PodSecurityProfile = string(e.podSecurity)
+	// a deleted instance is only observed until its objects are gone, an
+	// invalid spec must not keep it from being deleted
+	if !meta.WasDeleted(ps) {
+		if err := validate(ps); err != nil {
+			return managed.ExternalObservation{}, err
+		}
+	}
+	// an instance that conflicts with an older one owns none of the objects
+	// with its names. It does not exist, Create reports the conflict and a
+	// deleted instance is removed without touching the objects.
+	other, err := e.olderConflict(ctx, ps)
+	if err != nil || other != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	if postgres.IsReplicated(ps) {
+		return e.observeReplicated(ctx, ps)
+	}
 
 	// check deployment status
 	dpl := &appsv1.Deployment{}
-	err := e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, dpl)
+	err = e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, dpl)
+	if kerrors.IsNotFound(err) {
+		if err := e.checkBackend(ctx, ps, &appsv1.StatefulSet{}); err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		return e.observeDeletion(ctx, ps)
+	}
 	if err != nil {
 		e.logger.Debug(errDeploymentMsg, "err", err)
-		return managed.ExternalObservation{}, errors.Wrap(resource.IgnoreNotFound(err), errDeploymentMsg)
+		return managed.ExternalObservation{}, errors.Wrap(err, errDeploymentMsg)
+	}
+	if meta.WasDeleted(ps) {
+		return managed.ExternalObservation{ResourceExists: clients.IsOwnedBy(dpl, ps)}, nil
+	}
+
+	// a Service or PVC removed from the cluster is recreated by Update
+	svc := &v1.Service{}
+	err = e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, svc)
+	if kerrors.IsNotFound(err) {
+		return managed.ExternalObservation{ResourceExists: true}, nil
+	}
+	if err != nil {
+		e.logger.Debug(errServiceMsg, "err", err)
+		return managed.ExternalObservation{ResourceExists: true}, errors.Wrap(err, errServiceMsg)
+	}
+
+	pvc := &v1.PersistentVolumeClaim{}
+	err = e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, pvc)
+	if kerrors.IsNotFound(err) {
+		return managed.ExternalObservation{ResourceExists: true}, nil
+	}
+	if err != nil {
+		e.logger.Debug(errPVCMsg, "err", err)
+		return managed.ExternalObservation{ResourceExists: true}, errors.Wrap(err, errPVCMsg)
+	}
+
+	ps.Status.AtProvider.StorageResizeStatus = postgres.StorageResizeStatus(pvc)
+	ps.Status.AtProvider.Rollout = postgres.DeploymentRollout(dpl)
+	postgres.SetStorageStatus(ps, pvc)
+
+	pods, err := e.instancePods(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	previousImage := ps.Status.AtProvider.Image
+	postgres.SetPodStatus(ps, postgres.ObservedPod(pods.Items, ""))
+
+	password, stored, err := e.currentPassword(ctx, ps, dpl.Spec.Template)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	tlsSecret, renewTLS, err := e.observeTLS(ctx, ps, time.Now())
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	upToDate, err := isUpToDate(ps, dpl, svc, pvc)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	// instances created before the password Secret are migrated by Update
+	upToDate = upToDate && stored
+	// a missing or expiring certificate is generated by Update
+	upToDate = upToDate && !renewTLS
+
+	configUpToDate, err := e.isConfigUpToDate(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	// changed parameters are reloaded by Update
+	upToDate = upToDate && configUpToDate
+
+	namespaceUpToDate, err := e.isNamespaceUpToDate(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	upToDate = upToDate && namespaceUpToDate
+
+	monitoringUpToDate, err := e.isMonitoringUpToDate(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
 	}
+	upToDate = upToDate && monitoringUpToDate
 
 	// check if deployment is ready and return connection details
 	dplAvailable := false
@@ -136,27 +262,88 @@ func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.E
 		}
 	}
 
-	// deployment is in progress
-	if !dplAvailable {
+	restored, retryRestore, err := e.observeRestore(ctx, ps, dplAvailable)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	// a failed restore is retried by Update
+	upToDate = upToDate && !retryRestore
+
+	_, rotate, err := e.passwordRotation(ctx, ps, password, time.Now())
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	upToDate = upToDate && !rotate
+
+	// deployment or restore is in progress
+	if !dplAvailable || !restored {
 		e.logger.Debug("deployment currently not available")
-		return managed.ExternalObservation{ResourceExists: true}, nil
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, nil
 	}
 
-	svc := &v1.Service{}
-	err = e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, svc)
+	extensionsUpToDate, err := e.observeExtensions(ctx, ps)
 	if err != nil {
-		e.logger.Debug(errServiceMsg, "err", err)
-		return managed.ExternalObservation{ResourceExists: true}, errors.Wrap(err, errServiceMsg)
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	upToDate = upToDate && extensionsUpToDate
+	roleUpToDate, err := e.observeMonitoringRole(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
 	}
+	upToDate = upToDate && roleUpToDate
+	e.observeServerVersion(ctx, ps, previousImage)
 
-	ip := svc.Spec.ClusterIP
-	e.logger.Debug("postgres service", "ip", fmt.Sprintf("%+v", ip))
+	host, port, err := e.serviceAddress(ctx, svc)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	if host == "" {
+		e.logger.Debug("address of postgres service not yet known", "type", svc.Spec.Type)
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, nil
+	}
 
 	ps.SetConditions(runtimev1alpha1.Available())
 
-	return managed.ExternalObservation{ConnectionDetails: map[string][]byte{
-		runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(ip),
-	}, ResourceExists: true, ResourceUpToDate: true}, nil
+	details := postgres.AddTLSConnectionDetails(connectionDetails(ps, password), ps, tlsSecret)
+	details[runtimev1alpha1.ResourceCredentialsSecretEndpointKey] = []byte(host)
+	details[runtimev1alpha1.ResourceCredentialsSecretPortKey] = []byte(strconv.Itoa(port))
+	details, err = completeConnectionDetails(ps, details)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	return managed.ExternalObservation{ConnectionDetails: details, ResourceExists: true, ResourceUpToDate: upToDate}, nil
+}
+
+// validate checks the parts of the spec which cannot be validated by the CRD
+func validate(ps *v1alpha1.Postgres) error {
+	if err := postgres.ValidateParameters(ps); err != nil {
+		return err
+	}
+	if err := postgres.ValidateExtensions(ps.Spec.ForProvider.Extensions); err != nil {
+		return err
+	}
+	if err := postgres.ValidateMonitoring(ps); err != nil {
+		return err
+	}
+	return postgres.ValidateConnectionTemplates(ps)
+}
+
+// isUpToDate compares the observed objects against what the provider would
+// render for the current spec
+func isUpToDate(ps *v1alpha1.Postgres, dpl *appsv1.Deployment, svc *v1.Service, pvc *v1.PersistentVolumeClaim) (bool, error) {
+	desiredPVC, err := postgres.MakePVCPostgres(ps)
+	if err != nil {
+		return false, errors.Wrap(err, errPVCMsg)
+	}
+	desiredDpl := postgres.MakePostgresDeployment(ps)
+	desiredSvc := postgres.MakeDefaultPostgresService(ps)
+	owned, err := observeOwners(owned{dpl, desiredDpl}, owned{svc, desiredSvc}, owned{pvc, desiredPVC})
+	if err != nil {
+		return false, err
+	}
+	return owned && postgres.IsDeploymentUpToDate(desiredDpl, dpl) &&
+		postgres.IsServiceUpToDate(desiredSvc, svc) &&
+		postgres.IsPVCUpToDate(desiredPVC, pvc), nil
 }
 
 func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
@@ -164,6 +351,15 @@ func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.Ex
 	if !ok {
 		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
 	}
+	if err := e.checkConflicts(ctx, ps); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if err := e.applyNamespace(ctx, ps); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if err := e.checkSnapshotSource(ctx, ps); err != nil {
+		return managed.ExternalCreation{}, err
+	}
 	pvc, err := postgres.MakePVCPostgres(ps)
 	if err != nil {
 		return managed.ExternalCreation{}, errors.Wrap(err, errPVCCreateMsg)
@@ -172,39 +368,191 @@ func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.Ex
 		return managed.ExternalCreation{}, errors.Wrap(err, errPVCCreateMsg)
 	}
 	// deploy credentials secret
-	password, err := e.client.ParseInputSecret(ctx, *ps)
-	if err != nil || password == "" {
-		password, err = e.client.GeneratePassword()
-		if err != nil {
-			return managed.ExternalCreation{}, errors.Wrap(err, errGeneratePasswordMsg)
-		}
+	password, err := e.initialPassword(ctx, ps)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePasswordSecret(ps, password)); err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errInstanceSecretCreateMsg)
+	}
+	tlsSecret, err := e.renewTLS(ctx, ps)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	// new pods load the configuration when they start
+	if err := e.applyConfig(ctx, ps); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	ps.Status.AtProvider.ConfigChecksum = postgres.ConfigChecksum(postgres.MakePostgresConfigMap(ps))
+	if err := e.applyMonitoringSecret(ctx, ps); err != nil {
+		return managed.ExternalCreation{}, err
 	}
 
-	// deploy deployment
-	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePostgresDeployment(ps, password)); err != nil {
-		return managed.ExternalCreation{}, errors.Wrap(err, errDeployCreateMsg)
+	if postgres.IsReplicated(ps) {
+		if err := e.createReplicated(ctx, ps); err != nil {
+			return managed.ExternalCreation{}, err
+		}
+	} else {
+		// deploy deployment
+		if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePostgresDeployment(ps)); err != nil {
+			return managed.ExternalCreation{}, errors.Wrap(err, errDeployCreateMsg)
+		}
+		// deploy service
+		if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeDefaultPostgresService(ps)); err != nil {
+			return managed.ExternalCreation{}, errors.Wrap(err, errSVCCreateMsg)
+		}
+		if postgres.IsMonitored(ps) {
+			if err := e.applyServiceMonitor(ctx, ps); err != nil {
+				return managed.ExternalCreation{}, err
+			}
+		}
 	}
-	// deploy service
-	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeDefaultPostgresService(ps)); err != nil {
-		return managed.ExternalCreation{}, errors.Wrap(err, errSVCCreateMsg)
+
+	if ps.Spec.ForProvider.Source != nil {
+		if err := e.restore(ctx, ps); err != nil {
+			return managed.ExternalCreation{}, err
+		}
 	}
 
-	return managed.ExternalCreation{
-		ConnectionDetails: map[string][]byte{
-			runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(utils.StringValue(ps.Spec.ForProvider.MasterUsername)),
-			runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(password),
-			runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte(strconv.Itoa(utils.IntValue(ps.Spec.ForProvider.Port))),
-			ResourceCredentialsSecretDatabaseKey:                 []byte(utils.StringValue(ps.Spec.ForProvider.Database)),
-		},
-	}, nil
+	details, err := completeConnectionDetails(ps, postgres.AddTLSConnectionDetails(connectionDetails(ps, password), ps, tlsSecret))
+	return managed.ExternalCreation{ConnectionDetails: details}, err
+}
+
+// connectionDetails returns the connection details of the master user
+func connectionDetails(ps *v1alpha1.Postgres, password string) managed.ConnectionDetails {
+	return managed.ConnectionDetails{
+		runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(utils.StringValue(ps.Spec.ForProvider.MasterUsername)),
+		runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(password),
+		runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte(strconv.Itoa(utils.IntValue(ps.Spec.ForProvider.Port))),
+		postgres.ResourceCredentialsSecretDatabaseKey:        []byte(utils.StringValue(ps.Spec.ForProvider.Database)),
+	}
 }
 
 func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
-	_, ok := mgd.(*v1alpha1.Postgres)
+	ps, ok := mgd.(*v1alpha1.Postgres)
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
 	}
-	return managed.ExternalUpdate{}, nil
+	if err := e.applyNamespace(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+
+	if postgres.IsReplicated(ps) {
+		return e.updateReplicated(ctx, ps)
+	}
+
+	dpl := &appsv1.Deployment{}
+	if err := e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, dpl); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errDeploymentMsg)
+	}
+
+	switch postgres.CompareMajorVersions(postgres.Version(ps), postgres.ObservedVersion(dpl)) {
+	case -1:
+		return managed.ExternalUpdate{}, errors.Errorf(errDowngrade, postgres.ObservedVersion(dpl), postgres.Version(ps))
+	case 1:
+		if done, err := e.upgrade(ctx, ps, dpl); err != nil || !done {
+			return managed.ExternalUpdate{}, err
+		}
+	}
+
+	pvc, err := postgres.MakePVCPostgres(ps)
+	if err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errPVCCreateMsg)
+	}
+	observedPVC := &v1.PersistentVolumeClaim{}
+	err = e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, observedPVC)
+	if resource.IgnoreNotFound(err) != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errPVCMsg)
+	}
+	// a missing PVC is created with the requested size
+	if err == nil {
+		resize, err := e.checkResize(ctx, ps, pvc, observedPVC)
+		if err != nil {
+			return managed.ExternalUpdate{}, err
+		}
+		if !resize {
+			pvc.Spec.Resources.Requests = observedPVC.Spec.Resources.Requests
+		}
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, pvc); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errPVCCreateMsg)
+	}
+	password, rotated, err := e.storePassword(ctx, ps, dpl.Spec.Template)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	tlsSecret, err := e.renewTLS(ctx, ps)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.applyConfig(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.applyMonitoringSecret(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePostgresDeployment(ps)); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errDeployCreateMsg)
+	}
+	if err := e.cleanupUpgrade(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeDefaultPostgresService(ps)); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errSVCCreateMsg)
+	}
+	if err := e.reloadConfig(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.updateExtensions(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.updateMonitoring(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.retryRestore(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	return passwordUpdate(ps, password, rotated, tlsSecret)
+}
+
+// passwordUpdate republishes the connection details after the master password
+// was rotated
+func passwordUpdate(ps *v1alpha1.Postgres, password string, rotated bool, tlsSecret *v1.Secret) (managed.ExternalUpdate, error) {
+	if !rotated {
+		return managed.ExternalUpdate{}, nil
+	}
+	details, err := completeConnectionDetails(ps, postgres.AddTLSConnectionDetails(connectionDetails(ps, password), ps, tlsSecret))
+	return managed.ExternalUpdate{ConnectionDetails: details}, err
+}
+
+// checkResize makes sure a change of the requested storage can be applied to
+// the observed PVC, i.e. it is an increase and the StorageClass supports it.
+// A change which cannot be applied is reported on the StorageResize condition
+// and false is returned, so that the PVC keeps its size while the rest of the
+// instance is still updated.
+func (e *external) checkResize(ctx context.Context, ps *v1alpha1.Postgres, desired, pvc *v1.PersistentVolumeClaim) (bool, error) {
+	want, got := desired.Spec.Resources.Requests.Storage(), pvc.Spec.Resources.Requests.Storage()
+	var rejected error
+	switch want.Cmp(*got) {
+	case -1:
+		rejected = errors.Errorf(errShrinkPVC, got.String(), want.String())
+	case 1:
+		sc := &storagev1.StorageClass{}
+		if err := e.kube.Get(ctx, types.NamespacedName{Name: utils.StringValue(pvc.Spec.StorageClassName)}, sc); err != nil {
+			return false, errors.Wrap(err, errStorageClassMsg)
+		}
+		if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+			rejected = errors.Errorf(errExpansionDisabled, sc.Name)
+		}
+	}
+	if rejected != nil {
+		ps.SetConditions(v1alpha1.StorageResizeRejected(rejected))
+		return false, nil
+	}
+	if ps.GetCondition(v1alpha1.TypeStorageResize).Status == v1.ConditionFalse {
+		ps.SetConditions(v1alpha1.StorageResizeApplied())
+	}
+	return true, nil
 }
 
 func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
@@ -216,25 +564,60 @@ func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
 	if err != nil {
 		return errors.Wrap(err, errDelete)
 	}
+	if ps.Spec.ForProvider.Source != nil {
+		if err := e.client.DeletePostgresRestoreJob(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+	}
+	if postgres.IsReplicated(ps) {
+		if err := e.client.DeletePostgresReplication(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+		if err := e.client.DeletePostgresStatefulSet(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+		if err := e.client.DeletePostgresPasswordSecret(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+		if err := e.client.DeletePostgresConfig(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+		if err := e.client.DeletePostgresTLSSecret(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+		return e.reclaimPVCs(ctx, ps)
+	}
 	err = e.client.DeletePostgresDeployment(ctx, ps)
 	if err != nil {
 		return errors.Wrap(err, errDelete)
 	}
-	return errors.Wrap(e.client.DeletePostgresPVC(ctx, ps), errDelete)
+	if err := e.client.DeletePostgresPasswordSecret(ctx, ps); err != nil {
+		return errors.Wrap(err, errDelete)
+	}
+	if err := e.client.DeletePostgresConfig(ctx, ps); err != nil {
+		return errors.Wrap(err, errDelete)
+	}
+	if err := e.client.DeletePostgresTLSSecret(ctx, ps); err != nil {
+		return errors.Wrap(err, errDelete)
+	}
+	if hasMonitoring(ps) {
+		if err := e.client.DeletePostgresMonitoring(ctx, ps); err != nil {
+			return errors.Wrap(err, errDelete)
+		}
+	}
+	return e.reclaimPVCs(ctx, ps)
 }
 
 func initializeDefaults(pg *v1alpha1.Postgres) bool {
 	updated := false
-	// We need to set the default namespace here for the PV/PVC.
-	if strings.TrimSpace(pg.Namespace) == "" {
-		pg.Namespace = "default"
-	}
+	// the objects of the instance are created in this namespace
+	pg.Namespace = postgres.Namespace(pg)
 	if pg.Spec.ForProvider.StorageClass == nil {
 		pg.Spec.ForProvider.StorageClass = utils.String("Standard")
 		updated = true
 	}
 	if pg.Spec.ForProvider.MasterUsername == nil {
-		pg.Spec.ForProvider.MasterUsername = utils.String("postgres")
+		pg.Spec.ForProvider.MasterUsername = utils.String(postgres.DefaultMasterUsername)
 		updated = true
 	}
 	if pg.Spec.ForProvider.Database == nil {
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errDataClaimsMsg    = "failed to get the PVCs of the postgres instance"      //nolint:golint
+	errRetainMsg        = "failed to retain the PVCs of the postgres instance"   //nolint:golint
+	errFinalSnapshotMsg = "failed to snapshot the PVCs of the postgres instance" //nolint:golint
+)
+
+// reclaimPVCs deletes, retains or snapshots the PVCs of a deleted instance
+// according to its reclaim policy. With the Snapshot policy the PVCs are only
+// deleted once all snapshots are ready to use, until then the instance is
+// observed as existing and Delete is called again.
+func (e *external) reclaimPVCs(ctx context.Context, ps *v1alpha1.Postgres) error {
+	switch postgres.ReclaimPolicy(ps) {
+	case v1alpha1.ReclaimPolicyRetain:
+		return errors.Wrap(e.client.RetainPostgresPVC(ctx, ps), errRetainMsg)
+	case v1alpha1.ReclaimPolicySnapshot:
+		names, ready, err := e.client.SnapshotPostgresPVC(ctx, ps)
+		if len(names) > 0 {
+			ps.Status.AtProvider.FinalSnapshots = names
+		}
+		if err != nil || !ready {
+			return errors.Wrap(err, errFinalSnapshotMsg)
+		}
+	}
+	return errors.Wrap(e.client.DeletePostgresPVC(ctx, ps), errDelete)
+}
+
+// observeDeletion observes an instance whose Deployment or StatefulSet does
+// not exist. It only exists while it is deleted with the Snapshot reclaim
+// policy and its PVCs wait for their snapshots.
+func (e *external) observeDeletion(ctx context.Context, ps *v1alpha1.Postgres) (managed.ExternalObservation, error) {
+	if !meta.WasDeleted(ps) || postgres.ReclaimPolicy(ps) != v1alpha1.ReclaimPolicySnapshot {
+		return managed.ExternalObservation{}, nil
+	}
+	claims, err := e.client.DataClaims(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errDataClaimsMsg)
+	}
+	for _, pvc := range claims {
+		if pvc.DeletionTimestamp == nil {
+			return managed.ExternalObservation{ResourceExists: true}, nil
+		}
+	}
+	return managed.ExternalObservation{}, nil
+}
@@ -0,0 +1,401 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errStatefulSetMsg       = "failed to get postgres statefulset"                     //nolint:golint
+	errStatefulSetCreateMsg = "failed to create or update postgres statefulset"        //nolint:golint
+	errReplicationMsg       = "failed to get postgres replication config"              //nolint:golint
+	errReplicationCreateMsg = "failed to create or update postgres replication config" //nolint:golint
+	errPodsMsg              = "failed to list postgres pods"                           //nolint:golint
+	errPodRoleMsg           = "failed to label postgres pod with its role"             //nolint:golint
+	errSwitchBackend        = "cannot switch an existing instance between a single deployment and a replicated statefulset"
+	errReplicatedUpgrade    = "major version upgrades are only supported for instances without replicas"
+	errScaleDownPrimary     = "cannot scale down to %d replicas, which would remove the primary in pod %s"
+)
+
+// replicatedObjects are the observed objects making up a replicated instance
+type replicatedObjects struct {
+	sts     *appsv1.StatefulSet
+	cm      *v1.ConfigMap
+	svc     *v1.Service
+	ro      *v1.Service
+	pods    *v1.PodList
+	pvcs    *v1.PersistentVolumeClaimList
+	primary string
+	// missing is true if the ConfigMap or one of the Services does not exist
+	missing bool
+}
+
+// checkBackend returns an error if the instance exists with the other kind of
+// workload, i.e. replicas were added to or removed from an existing instance
+func (e *external) checkBackend(ctx context.Context, ps *v1alpha1.Postgres, other runtime.Object) error {
+	err := e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, other)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return errors.New(errSwitchBackend)
+}
+
+// getReplicated fetches the objects of a replicated instance. It returns nil
+// if the StatefulSet does not exist yet.
+func (e *external) getReplicated(ctx context.Context, ps *v1alpha1.Postgres) (*replicatedObjects, error) { //nolint:gocyclo
+	o := &replicatedObjects{
+		sts:  &appsv1.StatefulSet{},
+		cm:   &v1.ConfigMap{},
+		svc:  &v1.Service{},
+		ro:   &v1.Service{},
+		pods: &v1.PodList{},
+		pvcs: &v1.PersistentVolumeClaimList{},
+	}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, o.sts)
+	if kerrors.IsNotFound(err) {
+		return nil, e.checkBackend(ctx, ps, &appsv1.Deployment{})
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errStatefulSetMsg)
+	}
+	// a ConfigMap or Service removed from the cluster is recreated by Update
+	for _, obj := range []struct {
+		name string
+		obj  runtime.Object
+		msg  string
+	}{
+		{name: postgres.ReplicationConfigMapName(ps), obj: o.cm, msg: errReplicationMsg},
+		{name: ps.Name, obj: o.svc, msg: errServiceMsg},
+		{name: postgres.ReadOnlyServiceName(ps), obj: o.ro, msg: errServiceMsg},
+	} {
+		err := e.kube.Get(ctx, types.NamespacedName{Name: obj.name, Namespace: ps.Namespace}, obj.obj)
+		if kerrors.IsNotFound(err) {
+			o.missing = true
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, obj.msg)
+		}
+	}
+	selector := client.MatchingLabels{postgres.LabelStatefulSet: ps.Name}
+	if err := e.kube.List(ctx, o.pods, client.InNamespace(ps.Namespace), selector); err != nil {
+		return nil, errors.Wrap(err, errPodsMsg)
+	}
+	if err := e.kube.List(ctx, o.pvcs, client.InNamespace(ps.Namespace), selector); err != nil {
+		return nil, errors.Wrap(err, errPVCMsg)
+	}
+	// the primary recorded in the status survives a lost ConfigMap
+	o.primary = o.cm.Data[postgres.ReplicationPrimaryKey]
+	if o.primary == "" {
+		o.primary = ps.Status.AtProvider.Primary
+	}
+	if o.primary == "" {
+		o.primary = postgres.PrimaryPodName(ps)
+	}
+	return o, nil
+}
+
+func (e *external) observeReplicated(ctx context.Context, ps *v1alpha1.Postgres) (managed.ExternalObservation, error) {
+	o, err := e.getReplicated(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if o == nil {
+		return e.observeDeletion(ctx, ps)
+	}
+	if meta.WasDeleted(ps) {
+		return managed.ExternalObservation{ResourceExists: clients.IsOwnedBy(o.sts, ps)}, nil
+	}
+	if o.missing {
+		return managed.ExternalObservation{ResourceExists: true}, nil
+	}
+
+	password, stored, err := e.currentPassword(ctx, ps, o.sts.Spec.Template)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	tlsSecret, renewTLS, err := e.observeTLS(ctx, ps, time.Now())
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	upToDate, err := isReplicationUpToDate(ps, o)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	upToDate = upToDate && stored && !renewTLS
+	configUpToDate, err := e.isConfigUpToDate(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	upToDate = upToDate && configUpToDate
+	namespaceUpToDate, err := e.isNamespaceUpToDate(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	upToDate = upToDate && namespaceUpToDate
+	primaryReady := false
+	for i := range o.pods.Items {
+		if o.pods.Items[i].Name == o.primary {
+			primaryReady = postgres.IsPodReady(&o.pods.Items[i])
+		}
+	}
+	restored, retryRestore, err := e.observeRestore(ctx, ps, primaryReady)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	upToDate = upToDate && !retryRestore
+	_, rotate, err := e.passwordRotation(ctx, ps, password, time.Now())
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	upToDate = upToDate && !rotate
+	// a failover is carried out by Update
+	upToDate = upToDate && !needsFailover(o, time.Now())
+	ps.Status.AtProvider.Primary = o.primary
+
+	ps.Status.AtProvider.StorageResizeStatus = ""
+	for i := range o.pvcs.Items {
+		if s := postgres.StorageResizeStatus(&o.pvcs.Items[i]); s != "" {
+			ps.Status.AtProvider.StorageResizeStatus = s
+		}
+	}
+	// the primary is reported, as that is where clients connect to
+	ps.Status.AtProvider.Rollout = postgres.StatefulSetRollout(o.sts)
+	primaryPVC := &v1.PersistentVolumeClaim{}
+	for i := range o.pvcs.Items {
+		if o.pvcs.Items[i].Name == postgres.ClaimNameForPod(o.primary) {
+			primaryPVC = &o.pvcs.Items[i]
+		}
+	}
+	postgres.SetStorageStatus(ps, primaryPVC)
+	previousImage := ps.Status.AtProvider.Image
+	postgres.SetPodStatus(ps, postgres.ObservedPod(o.pods.Items, o.primary))
+
+	if !primaryReady || !restored {
+		e.logger.Debug("primary currently not ready")
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, nil
+	}
+
+	extensionsUpToDate, err := e.observeExtensions(ctx, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	upToDate = upToDate && extensionsUpToDate
+	e.observeServerVersion(ctx, ps, previousImage)
+
+	host, port, err := e.serviceAddress(ctx, o.svc)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	readerHost, readerPort, err := e.serviceAddress(ctx, o.ro)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	if host == "" || readerHost == "" {
+		e.logger.Debug("address of postgres services not yet known", "type", o.svc.Spec.Type)
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, nil
+	}
+
+	ps.SetConditions(runtimev1alpha1.Available())
+
+	details := postgres.AddTLSConnectionDetails(connectionDetails(ps, password), ps, tlsSecret)
+	details[runtimev1alpha1.ResourceCredentialsSecretEndpointKey] = []byte(host)
+	details[runtimev1alpha1.ResourceCredentialsSecretPortKey] = []byte(strconv.Itoa(port))
+	details[postgres.ResourceCredentialsSecretReaderEndpointKey] = []byte(readerHost)
+	details[postgres.ResourceCredentialsSecretReaderPortKey] = []byte(strconv.Itoa(readerPort))
+	details, err = completeConnectionDetails(ps, details)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	return managed.ExternalObservation{ConnectionDetails: details, ResourceExists: true, ResourceUpToDate: upToDate}, nil
+}
+
+// isReplicationUpToDate compares the observed objects of a replicated instance
+// against what the provider would render for the current spec
+func isReplicationUpToDate(ps *v1alpha1.Postgres, o *replicatedObjects) (bool, error) {
+	desiredSts, err := postgres.MakePostgresStatefulSet(ps)
+	if err != nil {
+		return false, errors.Wrap(err, errPVCMsg)
+	}
+	desiredPVC, err := postgres.MakePVCPostgres(ps)
+	if err != nil {
+		return false, errors.Wrap(err, errPVCMsg)
+	}
+	desiredCM := postgres.MakeReplicationConfigMap(ps, o.primary)
+	desiredSvc := postgres.MakePrimaryPostgresService(ps, o.primary)
+	desiredRO := postgres.MakeReadOnlyPostgresService(ps)
+	owned, err := observeOwners(owned{o.sts, desiredSts}, owned{o.cm, desiredCM}, owned{o.svc, desiredSvc}, owned{o.ro, desiredRO})
+	if err != nil {
+		return false, err
+	}
+	if !owned || !postgres.IsStatefulSetUpToDate(desiredSts, o.sts) ||
+		!postgres.IsConfigMapUpToDate(desiredCM, o.cm) ||
+		!postgres.IsServiceUpToDate(desiredSvc, o.svc) ||
+		!postgres.IsServiceUpToDate(desiredRO, o.ro) {
+		return false, nil
+	}
+	for i := range o.pvcs.Items {
+		if !postgres.IsPVCUpToDate(desiredPVC, &o.pvcs.Items[i]) {
+			return false, nil
+		}
+	}
+	for i := range o.pods.Items {
+		if o.pods.Items[i].Labels[postgres.LabelRole] != postgres.PodRole(&o.pods.Items[i], o.primary) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (e *external) createReplicated(ctx context.Context, ps *v1alpha1.Postgres) error {
+	sts, err := postgres.MakePostgresStatefulSet(ps)
+	if err != nil {
+		return errors.Wrap(err, errPVCCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeReplicationConfigMap(ps, postgres.PrimaryPodName(ps))); err != nil {
+		return errors.Wrap(err, errReplicationCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, sts); err != nil {
+		return errors.Wrap(err, errStatefulSetCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePrimaryPostgresService(ps, postgres.PrimaryPodName(ps))); err != nil {
+		return errors.Wrap(err, errSVCCreateMsg)
+	}
+	_, err = e.client.CreateOrUpdate(ctx, postgres.MakeReadOnlyPostgresService(ps))
+	return errors.Wrap(err, errSVCCreateMsg)
+}
+
+func (e *external) updateReplicated(ctx context.Context, ps *v1alpha1.Postgres) (managed.ExternalUpdate, error) { //nolint:gocyclo
+	o, err := e.getReplicated(ctx, ps)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if o == nil {
+		return managed.ExternalUpdate{}, errors.Wrap(kerrors.NewNotFound(appsv1.Resource("statefulsets"), ps.Name), errStatefulSetMsg)
+	}
+	if postgres.CompareMajorVersions(postgres.Version(ps), postgres.ObservedVersion(o.sts)) != 0 {
+		return managed.ExternalUpdate{}, errors.New(errReplicatedUpgrade)
+	}
+	if needsFailover(o, time.Now()) {
+		if err := e.failover(ctx, ps, o); err != nil {
+			return managed.ExternalUpdate{}, err
+		}
+	}
+	// the StatefulSet removes the pods with the highest ordinals, which must
+	// not include the primary after a failover
+	if replicas := utils.IntValue(ps.Spec.ForProvider.Replicas); postgres.PodOrdinal(ps, o.primary) > replicas {
+		return managed.ExternalUpdate{}, errors.Errorf(errScaleDownPrimary, replicas, o.primary)
+	}
+
+	for i := range o.pvcs.Items {
+		pvc, err := postgres.MakePVCPostgres(ps)
+		if err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errPVCCreateMsg)
+		}
+		pvc.Name = o.pvcs.Items[i].Name
+		resize, err := e.checkResize(ctx, ps, pvc, &o.pvcs.Items[i])
+		if err != nil {
+			return managed.ExternalUpdate{}, err
+		}
+		if !resize {
+			pvc.Spec.Resources.Requests = o.pvcs.Items[i].Spec.Resources.Requests
+		}
+		if _, err := e.client.CreateOrUpdate(ctx, pvc); err != nil {
+			return managed.ExternalUpdate{}, errors.Wrap(err, errPVCCreateMsg)
+		}
+	}
+
+	password, rotated, err := e.storePassword(ctx, ps, o.sts.Spec.Template)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	tlsSecret, err := e.renewTLS(ctx, ps)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	sts, err := postgres.MakePostgresStatefulSet(ps)
+	if err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errPVCCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeReplicationConfigMap(ps, o.primary)); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errReplicationCreateMsg)
+	}
+	if err := e.applyConfig(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, sts); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errStatefulSetCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePrimaryPostgresService(ps, o.primary)); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errSVCCreateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakeReadOnlyPostgresService(ps)); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errSVCCreateMsg)
+	}
+	for i := range o.pods.Items {
+		pod := &o.pods.Items[i]
+		if role := postgres.PodRole(pod, o.primary); pod.Labels[postgres.LabelRole] != role {
+			if err := e.client.SetPodRole(ctx, pod, role); err != nil {
+				return managed.ExternalUpdate{}, errors.Wrap(err, errPodRoleMsg)
+			}
+		}
+	}
+	if rotated {
+		// the standbys log in to the primary with the password from their
+		// environment, which is only read when they start
+		for i := range o.pods.Items {
+			if o.pods.Items[i].Name == o.primary {
+				continue
+			}
+			if err := e.client.DeletePod(ctx, &o.pods.Items[i]); err != nil {
+				return managed.ExternalUpdate{}, errors.Wrap(err, errRestartStandbyMsg)
+			}
+		}
+	}
+	if err := e.reloadConfig(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.updateExtensions(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if err := e.retryRestore(ctx, ps); err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	return passwordUpdate(ps, password, rotated, tlsSecret)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errRestoreJobMsg    = "failed to get postgres restore job"    //nolint:golint
+	errRestoreCreateMsg = "failed to create postgres restore job" //nolint:golint
+	errRestoreSourceMsg = "failed to get the source of postgres"  //nolint:golint
+	errSourceUnset      = "exactly one of backup, pvc, postgres and snapshot has to be set as source"
+	errNoBackup         = "postgres backup %s has not taken a successful backup yet"
+	errRestoreFailed    = "restore from %s failed, delete job %s to retry"
+)
+
+// observeRestore checks the progress of restoring the source of a new
+// instance. It returns true once the instance is ready to be used, and whether
+// a failed restore has to be retried because its Job was deleted. Instances
+// which were not created from their source are never restored. ready tells
+// whether the instance accepts connections.
+func (e *external) observeRestore(ctx context.Context, ps *v1alpha1.Postgres, ready bool) (bool, bool, error) {
+	status := ps.Status.AtProvider.Restore
+	if ps.Spec.ForProvider.Source == nil || (status != nil && status.Phase == v1alpha1.RestorePhaseSucceeded) {
+		return true, false, nil
+	}
+	if ps.Spec.ForProvider.Source.Snapshot != nil {
+		restored, err := e.observeSnapshotRestore(ctx, ps, ready)
+		return restored, false, err
+	}
+
+	job := &batchv1.Job{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: postgres.RestoreJobName(ps), Namespace: ps.Namespace}, job)
+	if kerrors.IsNotFound(err) {
+		return status == nil, status != nil, nil
+	}
+	if err != nil {
+		return false, false, errors.Wrap(err, errRestoreJobMsg)
+	}
+	if status == nil {
+		status = &v1alpha1.PostgresRestoreStatus{}
+		ps.Status.AtProvider.Restore = status
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			status.Phase = v1alpha1.RestorePhaseSucceeded
+			return true, false, nil
+		case batchv1.JobFailed:
+			status.Phase = v1alpha1.RestorePhaseFailed
+			return false, false, errors.Errorf(errRestoreFailed, status.Source, job.Name)
+		}
+	}
+	status.Phase = v1alpha1.RestorePhaseRestoring
+	return false, false, nil
+}
+
+// restore creates the Job restoring the source into the new instance. An
+// instance provisioned from a snapshot needs no Job.
+func (e *external) restore(ctx context.Context, ps *v1alpha1.Postgres) error {
+	if ps.Spec.ForProvider.Source.Snapshot != nil {
+		ps.Status.AtProvider.Restore = &v1alpha1.PostgresRestoreStatus{Source: snapshotSourceName(ps), Phase: v1alpha1.RestorePhaseRestoring}
+		return nil
+	}
+	src, err := e.restoreSource(ctx, ps)
+	if err != nil {
+		return err
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePostgresRestoreJob(ps, src)); err != nil {
+		return errors.Wrap(err, errRestoreCreateMsg)
+	}
+	ps.Status.AtProvider.Restore = &v1alpha1.PostgresRestoreStatus{Source: src.String(), Phase: v1alpha1.RestorePhaseRestoring}
+	return nil
+}
+
+// retryRestore recreates the restore Job of a failed restore after the failed
+// Job has been deleted
+func (e *external) retryRestore(ctx context.Context, ps *v1alpha1.Postgres) error {
+	// only restores from a Job are retried, which does not need the instance
+	_, retry, err := e.observeRestore(ctx, ps, false)
+	if err != nil || !retry {
+		return err
+	}
+	return e.restore(ctx, ps)
+}
+
+// restoreSource resolves the source of the instance. Backups and instances
+// are looked up in the cluster the managed resources live in. The password of
+// a source instance is only read when its password Secret cannot be referenced
+// by the restore Job.
+func (e *external) restoreSource(ctx context.Context, ps *v1alpha1.Postgres) (*postgres.RestoreSource, error) {
+	s := ps.Spec.ForProvider.Source
+	switch {
+	case s.Backup != nil:
+		b := &v1alpha1.PostgresBackup{}
+		if err := e.local.Get(ctx, types.NamespacedName{Name: s.Backup.Name}, b); err != nil {
+			return nil, errors.Wrap(err, errRestoreSourceMsg)
+		}
+		location := utils.StringValueFallback(s.Backup.Location, b.Status.AtProvider.LastBackupLocation)
+		if location == "" {
+			return nil, errors.Errorf(errNoBackup, b.Name)
+		}
+		if d := b.Spec.ForProvider.Destination; d.PVC != nil {
+			return &postgres.RestoreSource{File: location, ClaimName: d.PVC.ClaimName}, nil
+		}
+		return &postgres.RestoreSource{File: location, S3: b.Spec.ForProvider.Destination.S3}, nil
+	case s.PVC != nil:
+		return &postgres.RestoreSource{File: s.PVC.Path, ClaimName: s.PVC.ClaimName}, nil
+	case s.Postgres != nil:
+		src, err := postgres.GetInstance(ctx, e.local, s.Postgres.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, errRestoreSourceMsg)
+		}
+		if src.Namespace == ps.Namespace {
+			return &postgres.RestoreSource{Instance: src}, nil
+		}
+		pw, err := postgres.InstancePassword(ctx, e.kube, src)
+		if err != nil {
+			return nil, errors.Wrap(err, errRestoreSourceMsg)
+		}
+		return &postgres.RestoreSource{Instance: src, Password: pw}, nil
+	}
+	return nil, errors.New(errSourceUnset)
+}
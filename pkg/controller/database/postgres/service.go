@@ -0,0 +1,46 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errNodesMsg = "failed to list nodes for postgres node port" //nolint:golint
+)
+
+// serviceAddress returns the host and port clients reach the given Service
+// at. The host is empty while it is not known yet, e.g. the load balancer is
+// still provisioned.
+func (e *external) serviceAddress(ctx context.Context, svc *v1.Service) (string, int, error) {
+	var nodes []v1.Node
+	if svc.Spec.Type == v1.ServiceTypeNodePort {
+		l := &v1.NodeList{}
+		if err := e.kube.List(ctx, l); err != nil {
+			return "", 0, errors.Wrap(err, errNodesMsg)
+		}
+		nodes = l.Items
+	}
+	host, port := postgres.ServiceAddress(svc, nodes)
+	return host, port, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errGetSnapshotMsg      = "failed to get the postgres snapshot of the source"          //nolint:golint
+	errSnapshotPasswordMsg = "failed to set the master password of the restored instance" //nolint:golint
+	errSnapshotNotReady    = "postgres snapshot %s is not ready to use yet"
+)
+
+// checkSnapshotSource checks that the PostgresSnapshot the instance is
+// provisioned from is ready and fits its PVC. Instances from another source
+// are not checked.
+func (e *external) checkSnapshotSource(ctx context.Context, ps *v1alpha1.Postgres) error {
+	s := ps.Spec.ForProvider.Source
+	if s == nil || s.Snapshot == nil {
+		return nil
+	}
+	snap := &v1alpha1.PostgresSnapshot{}
+	if err := e.local.Get(ctx, types.NamespacedName{Name: s.Snapshot.Name}, snap); err != nil {
+		return errors.Wrap(err, errGetSnapshotMsg)
+	}
+	if !snap.Status.AtProvider.ReadyToUse {
+		return errors.Errorf(errSnapshotNotReady, snap.Name)
+	}
+	return postgres.ValidateSnapshotSource(ps, snap)
+}
+
+// observeSnapshotRestore completes the restore of an instance provisioned
+// from a snapshot. The data of the snapshot carries the master password of
+// the instance it was taken of, so the password of the new instance is set
+// once it accepts connections. Until then, i.e. while ready is false, the
+// restore is in progress.
+func (e *external) observeSnapshotRestore(ctx context.Context, ps *v1alpha1.Postgres, ready bool) (bool, error) {
+	status := ps.Status.AtProvider.Restore
+	if status == nil {
+		status = &v1alpha1.PostgresRestoreStatus{Source: snapshotSourceName(ps)}
+		ps.Status.AtProvider.Restore = status
+	}
+	status.Phase = v1alpha1.RestorePhaseRestoring
+	if !ready {
+		return false, nil
+	}
+	pw, err := postgres.InstancePassword(ctx, e.kube, ps)
+	if err != nil {
+		return false, errors.Wrap(err, errSnapshotPasswordMsg)
+	}
+	stmt := fmt.Sprintf("ALTER USER %s WITH PASSWORD %s",
+		postgres.QuoteIdentifier(postgres.MasterUsername(ps)), postgres.QuoteLiteral(pw))
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, stmt); err != nil {
+		return false, errors.Wrap(err, errSnapshotPasswordMsg)
+	}
+	status.Phase = v1alpha1.RestorePhaseSucceeded
+	return true, nil
+}
+
+func snapshotSourceName(ps *v1alpha1.Postgres) string {
+	return "snapshot " + ps.Spec.ForProvider.Source.Snapshot.Name
+}
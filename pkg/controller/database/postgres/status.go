@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errServerVersionMsg = "failed to observe postgres server version" //nolint:golint
+)
+
+// instancePods lists the pods of the Deployment or StatefulSet of the
+// instance
+func (e *external) instancePods(ctx context.Context, ps *v1alpha1.Postgres) (*v1.PodList, error) {
+	selector := client.MatchingLabels{"deployment": ps.Name}
+	if postgres.IsReplicated(ps) {
+		selector = client.MatchingLabels{postgres.LabelStatefulSet: ps.Name}
+	}
+	pods := &v1.PodList{}
+	if err := e.kube.List(ctx, pods, client.InNamespace(ps.Namespace), selector); err != nil {
+		return nil, errors.Wrap(err, errPodsMsg)
+	}
+	return pods, nil
+}
+
+// observeServerVersion records the version reported by the running server.
+// It is only queried again once the image changed, and failing to query it
+// does not fail the observation as it is informational only.
+func (e *external) observeServerVersion(ctx context.Context, ps *v1alpha1.Postgres, previousImage string) {
+	s := &ps.Status.AtProvider
+	if s.ServerVersion != "" && s.Image == previousImage {
+		return
+	}
+	out, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, postgres.ServerVersionQuery)
+	if err != nil {
+		e.logger.Debug(errServerVersionMsg, "err", err)
+		return
+	}
+	s.ServerVersion = postgres.ParseServerVersion(out)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errTLSSecretMsg           = "failed to get postgres TLS secret"              //nolint:golint
+	errTLSSecretCreateMsg     = "failed to create or update postgres TLS secret" //nolint:golint
+	errGenerateCertificateMsg = "failed to generate postgres certificate"        //nolint:golint
+	errCertificateMsg         = "invalid certificate in postgres TLS secret"     //nolint:golint
+
+	reasonCertificateRenewed event.Reason = "RenewedCertificate"
+)
+
+// observeTLS returns the Secret holding the certificate of an instance with
+// TLS and whether the provider has to generate a new certificate, because it
+// is missing or about to expire. The expiry of the certificate is recorded in
+// the status, it is rendered into the pod template so that a new certificate
+// restarts the instance.
+func (e *external) observeTLS(ctx context.Context, ps *v1alpha1.Postgres, now time.Time) (*v1.Secret, bool, error) {
+	if ps.Spec.ForProvider.TLS == nil {
+		ps.Status.AtProvider.CertificateNotAfter = nil
+		return nil, false, nil
+	}
+	s := &v1.Secret{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: postgres.TLSSecretName(ps), Namespace: ps.Namespace}, s)
+	if kerrors.IsNotFound(err) && postgres.IsTLSGenerated(ps) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, errTLSSecretMsg)
+	}
+	if postgres.IsTLSGenerated(ps) && postgres.IsCertificateDue(ps, s, now) {
+		return s, true, nil
+	}
+	cert, err := postgres.ParseCertificate(s, v1.TLSCertKey)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errCertificateMsg)
+	}
+	ps.Status.AtProvider.CertificateNotAfter = &metav1.Time{Time: cert.NotAfter}
+	return s, false, nil
+}
+
+// renewTLS generates the certificate of the instance if it is due and
+// returns the Secret holding the certificate the instance runs with.
+func (e *external) renewTLS(ctx context.Context, ps *v1alpha1.Postgres) (*v1.Secret, error) {
+	now := time.Now()
+	s, due, err := e.observeTLS(ctx, ps, now)
+	if err != nil || !due {
+		return s, err
+	}
+	renewed := s != nil
+	s, err = postgres.MakeTLSSecret(ps, s, now)
+	if err != nil {
+		return nil, errors.Wrap(err, errGenerateCertificateMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, s); err != nil {
+		return nil, errors.Wrap(err, errTLSSecretCreateMsg)
+	}
+	cert, err := postgres.ParseCertificate(s, v1.TLSCertKey)
+	if err != nil {
+		return nil, errors.Wrap(err, errCertificateMsg)
+	}
+	ps.Status.AtProvider.CertificateNotAfter = &metav1.Time{Time: cert.NotAfter}
+	if renewed {
+		e.recorder.Event(ps, event.Normal(reasonCertificateRenewed, "Renewed the TLS certificate"))
+	}
+	return s, nil
+}
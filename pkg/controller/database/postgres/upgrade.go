@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errUpgradeJobMsg     = "failed to get postgres upgrade job"                            //nolint:golint
+	errUpgradeCreateMsg  = "failed to create postgres upgrade job"                         //nolint:golint
+	errSnapshotMsg       = "failed to create pre-upgrade snapshot of the PVC"              //nolint:golint
+	errSnapshotDeleteMsg = "failed to delete the outdated pre-upgrade snapshot of the PVC" //nolint:golint
+	errScaleMsg          = "failed to scale postgres deployment for upgrading"             //nolint:golint
+	errUpgradeFailed     = "upgrade from version %s to %s failed and was rolled back, delete job %s to retry"
+)
+
+// upgrade drives a major version upgrade of the instance by stopping it,
+// cloning its PVC and running pg_upgrade in a Job. It returns true once the
+// data directory has been upgraded and the Deployment can be rolled to the new
+// version.
+func (e *external) upgrade(ctx context.Context, ps *v1alpha1.Postgres, dpl *appsv1.Deployment) (bool, error) {
+	status := &v1alpha1.PostgresUpgradeStatus{
+		FromVersion: postgres.ObservedVersion(dpl),
+		ToVersion:   postgres.Version(ps),
+	}
+	ps.Status.AtProvider.Upgrade = status
+
+	job := &batchv1.Job{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: postgres.UpgradeJobName(ps), Namespace: ps.Namespace}, job)
+	if resource.IgnoreNotFound(err) != nil {
+		return false, errors.Wrap(err, errUpgradeJobMsg)
+	}
+	if err == nil {
+		return e.finishUpgrade(ctx, ps, dpl, job)
+	}
+
+	// the old cluster has to be shut down for pg_upgrade and the snapshot to
+	// see a consistent data directory
+	if utils.Int32Value(dpl.Spec.Replicas) != 0 || dpl.Status.Replicas != 0 {
+		status.Phase = v1alpha1.UpgradePhaseScalingDown
+		return false, errors.Wrap(e.scale(ctx, dpl, 0), errScaleMsg)
+	}
+
+	// a clone left by a previous upgrade holds outdated data, it is deleted
+	// and taken again once it is gone
+	if deleted, err := e.deletePreUpgradeSnapshot(ctx, ps); err != nil || !deleted {
+		return false, err
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := e.kube.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: ps.Namespace}, pvc); err != nil {
+		return false, errors.Wrap(err, errPVCMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePreUpgradeSnapshotPVC(ps, pvc)); err != nil {
+		return false, errors.Wrap(err, errSnapshotMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePostgresUpgradeJob(ps, status.FromVersion, status.ToVersion)); err != nil {
+		return false, errors.Wrap(err, errUpgradeCreateMsg)
+	}
+	status.Phase = v1alpha1.UpgradePhaseUpgrading
+	return false, nil
+}
+
+// deletePreUpgradeSnapshot deletes the clone of the PVC taken before a
+// previous upgrade. It returns true once no clone exists anymore.
+func (e *external) deletePreUpgradeSnapshot(ctx context.Context, ps *v1alpha1.Postgres) (bool, error) {
+	clone := &v1.PersistentVolumeClaim{}
+	err := e.kube.Get(ctx, types.NamespacedName{Name: postgres.PreUpgradeSnapshotName(ps), Namespace: ps.Namespace}, clone)
+	if kerrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errSnapshotDeleteMsg)
+	}
+	// a clone owned by someone else is reported by CreateOrUpdate
+	if !clients.IsOwnedBy(clone, ps) {
+		return true, nil
+	}
+	if clone.DeletionTimestamp == nil {
+		if err := e.kube.Delete(ctx, clone); resource.IgnoreNotFound(err) != nil {
+			return false, errors.Wrap(err, errSnapshotDeleteMsg)
+		}
+	}
+	return false, nil
+}
+
+// finishUpgrade checks the outcome of the upgrade Job. A failed upgrade is
+// rolled back by starting the old version again, which works on the untouched
+// old data directory. The failed Job is kept so that the upgrade is not retried
+// until it has been deleted. A completed Job is kept until the Deployment has
+// been rolled to the new version, see cleanupUpgrade.
+func (e *external) finishUpgrade(ctx context.Context, ps *v1alpha1.Postgres, dpl *appsv1.Deployment, job *batchv1.Job) (bool, error) {
+	status := ps.Status.AtProvider.Upgrade
+	for _, c := range job.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			status.Phase = v1alpha1.UpgradePhaseSucceeded
+			return true, nil
+		case batchv1.JobFailed:
+			status.Phase = v1alpha1.UpgradePhaseFailed
+			if err := e.scale(ctx, dpl, 1); err != nil {
+				return false, errors.Wrap(err, errScaleMsg)
+			}
+			return false, errors.Errorf(errUpgradeFailed, status.FromVersion, status.ToVersion, job.Name)
+		}
+	}
+	status.Phase = v1alpha1.UpgradePhaseUpgrading
+	return false, nil
+}
+
+// cleanupUpgrade deletes the Job of a succeeded upgrade once the Deployment
+// has been rolled to the new version. Until then the completed Job tells the
+// next reconcile that the data directory is already upgraded, so that it
+// neither takes a new clone nor runs pg_upgrade again.
+func (e *external) cleanupUpgrade(ctx context.Context, ps *v1alpha1.Postgres) error {
+	status := ps.Status.AtProvider.Upgrade
+	if status == nil || status.Phase != v1alpha1.UpgradePhaseSucceeded || status.ToVersion != postgres.Version(ps) {
+		return nil
+	}
+	return errors.Wrap(e.client.DeletePostgresUpgradeJob(ctx, ps), errUpgradeJobMsg)
+}
+
+// scale sets the number of replicas of the observed Deployment without
+// changing its pod template
+func (e *external) scale(ctx context.Context, dpl *appsv1.Deployment, replicas int32) error {
+	scaled := dpl.DeepCopy()
+	scaled.Spec.Replicas = utils.Int32(replicas)
+	_, err := e.client.CreateOrUpdate(ctx, scaled)
+	return err
+}
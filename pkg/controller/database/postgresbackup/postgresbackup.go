@@ -0,0 +1,254 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresbackup
+
+import (
+	"context"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/apis/v1beta1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errUnexpectedObject = "the managed resource is not a PostgresBackup resource" //nolint:golint
+	errNoPostgres       = "the postgres instance of the backup is not set"
+	errGetPostgresMsg   = "failed to get postgres instance"                     //nolint:golint
+	errCronJobMsg       = "failed to get postgres backup cron job"              //nolint:golint
+	errCronJobCreateMsg = "failed to create or update postgres backup cron job" //nolint:golint
+	errJobsMsg          = "failed to list postgres backup jobs"                 //nolint:golint
+	errPodsMsg          = "failed to list pods of postgres backup job"          //nolint:golint
+	errDeleteMsg        = "failed to delete postgres backup cron job"           //nolint:golint
+)
+
+// SetupPostgresBackup adds a controller that reconciles PostgresBackups.
+func SetupPostgresBackup(mgr ctrl.Manager, l logging.Logger) error {
+	name := managed.ControllerName(v1alpha1.PostgresBackupGroupKind)
+	logger := l.WithValues("controller", name)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1alpha1.PostgresBackup{}).
+		Complete(managed.NewReconciler(mgr,
+			resource.ManagedKind(v1alpha1.PostgresBackupGroupVersionKind),
+			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: logger}),
+			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLogger(logger),
+			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+type connector struct {
+	kube        client.Client
+	newClientFn func(kube client.Client, cs kubernetes.Interface, rc *rest.Config) postgres.Client
+	logger      logging.Logger
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.PostgresBackup)
+	if !ok {
+		return nil, errors.New(errUnexpectedObject)
+	}
+
+	c.logger.Debug("Connecting")
+
+	pc, err := clients.GetProviderConfig(ctx, cr, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := clients.ProviderConfigRC(ctx, pc, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	kube, err := client.New(rc, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &external{client: c.newClientFn(kube, cs, rc), kube: c.kube, target: kube, logger: c.logger, podSecurity: clients.PodSecurityProfile(pc)}, nil
+}
+
+// external manages the CronJob taking the backups. kube is the client of the
+// cluster the managed resources live in, target the client of the cluster the
+// instance runs in.
+type external struct {
+	client      postgres.Client
+	kube        client.Client
+	target      client.Client
+	logger      logging.Logger
+	podSecurity v1beta1.PodSecurityProfile
+}
+
+func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresBackup)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errUnexpectedObject)
+	}
+	// the pods are rendered with the security profile of the provider config
+	cr.Status.AtProvider.PodSecurityProfile = string(e.podSecurity)
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	cj := &batchv1beta1.CronJob{}
+	err = e.target.Get(ctx, types.NamespacedName{Name: postgres.BackupName(cr), Namespace: ps.Namespace}, cj)
+	if kerrors.IsNotFound(err) {
+		return managed.ExternalObservation{}, nil
+	}
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errCronJobMsg)
+	}
+
+	desired, err := postgres.MakeBackupCronJob(cr, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	owned, err := clients.ObserveOwner(cj, desired)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	cr.Status.AtProvider.LastScheduleTime = cj.Status.LastScheduleTime
+	if err := e.observeLastBackup(ctx, cr, ps); err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	cr.SetConditions(runtimev1alpha1.Available())
+
+	return managed.ExternalObservation{
+		ResourceExists:   true,
+		ResourceUpToDate: owned && postgres.IsCronJobUpToDate(desired, cj),
+	}, nil
+}
+
+// instance fetches the Postgres instance which is backed up
+func (e *external) instance(ctx context.Context, cr *v1alpha1.PostgresBackup) (*v1alpha1.Postgres, error) {
+	if cr.Spec.ForProvider.PostgresName == nil {
+		return nil, errors.New(errNoPostgres)
+	}
+	ps, err := postgres.GetInstance(ctx, e.kube, *cr.Spec.ForProvider.PostgresName)
+	return ps, errors.Wrap(err, errGetPostgresMsg)
+}
+
+// observeLastBackup records the last successful backup in the status. Jobs
+// are garbage collected by the CronJob, so the status is only updated when a
+// more recent backup is found.
+func (e *external) observeLastBackup(ctx context.Context, cr *v1alpha1.PostgresBackup, ps *v1alpha1.Postgres) error {
+	jobs := &batchv1.JobList{}
+	if err := e.target.List(ctx, jobs, client.InNamespace(ps.Namespace), client.MatchingLabels{postgres.LabelBackup: cr.Name}); err != nil {
+		return errors.Wrap(err, errJobsMsg)
+	}
+	job := postgres.LastSuccessfulJob(jobs.Items)
+	last := cr.Status.AtProvider.LastSuccessfulBackupTime
+	if job == nil || (last != nil && !last.Before(job.Status.CompletionTime)) {
+		return nil
+	}
+
+	pods := &v1.PodList{}
+	if err := e.target.List(ctx, pods, client.InNamespace(ps.Namespace), postgres.JobPodSelector(job)); err != nil {
+		return errors.Wrap(err, errPodsMsg)
+	}
+	location, size, ok := postgres.BackupReport(pods.Items)
+	if !ok {
+		return nil
+	}
+	cr.Status.AtProvider.LastSuccessfulBackupTime = job.Status.CompletionTime.DeepCopy()
+	cr.Status.AtProvider.LastBackupLocation = location
+	cr.Status.AtProvider.LastBackupSizeBytes = size
+	return nil
+}
+
+func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresBackup)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
+	}
+	return managed.ExternalCreation{}, e.apply(ctx, cr)
+}
+
+func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresBackup)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
+	}
+	return managed.ExternalUpdate{}, e.apply(ctx, cr)
+}
+
+// apply creates or converges the CronJob
+func (e *external) apply(ctx context.Context, cr *v1alpha1.PostgresBackup) error {
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return err
+	}
+	cj, err := postgres.MakeBackupCronJob(cr, ps)
+	if err != nil {
+		return err
+	}
+	_, err = e.client.CreateOrUpdate(ctx, cj)
+	return errors.Wrap(err, errCronJobCreateMsg)
+}
+
+// Delete removes the CronJob and its Jobs. The backups taken are kept. A
+// CronJob the backup does not own is left alone.
+func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
+	cr, ok := mgd.(*v1alpha1.PostgresBackup)
+	if !ok {
+		return errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return err
+	}
+	cj := &batchv1beta1.CronJob{}
+	err = e.target.Get(ctx, types.NamespacedName{Name: postgres.BackupName(cr), Namespace: ps.Namespace}, cj)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errCronJobMsg)
+	}
+	if !clients.IsOwnedBy(cj, cr) {
+		return nil
+	}
+	err = e.target.Delete(ctx, cj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	return errors.Wrap(client.IgnoreNotFound(err), errDeleteMsg)
+}
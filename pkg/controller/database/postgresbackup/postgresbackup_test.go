@@ -0,0 +1,618 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresbackup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/apis/v1beta1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres/fake"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+var (
+	// an arbitrary managed resource
+	unexpectedItem resource.Managed
+	errBoom        = errors.New("boom")
+
+	backupName   = "nightly"
+	postgresName = "postgresdb"
+	schedule     = "0 3 * * *"
+	location     = "nightly/20201016T030000Z.dump"
+	completed    = metav1.NewTime(time.Date(2020, 10, 16, 3, 0, 5, 0, time.UTC))
+)
+
+type args struct {
+	pg          postgres.Client
+	kube        client.Client
+	target      client.Client
+	podSecurity v1beta1.PodSecurityProfile
+	cr          resource.Managed
+}
+
+// BackupModifier is a function which modifies the PostgresBackup for testing
+type BackupModifier func(cr *v1alpha1.PostgresBackup)
+
+func withSchedule(s string) BackupModifier {
+	return func(cr *v1alpha1.PostgresBackup) {
+		cr.Spec.ForProvider.Schedule = s
+	}
+}
+
+func withRetention(n int) BackupModifier {
+	return func(cr *v1alpha1.PostgresBackup) {
+		cr.Spec.ForProvider.Retention = &n
+	}
+}
+
+func withDestination(d v1alpha1.PostgresBackupDestination) BackupModifier {
+	return func(cr *v1alpha1.PostgresBackup) {
+		cr.Spec.ForProvider.Destination = d
+	}
+}
+
+func withLastBackup(t metav1.Time, location string, size int64) BackupModifier {
+	return func(cr *v1alpha1.PostgresBackup) {
+		cr.Status.AtProvider.LastSuccessfulBackupTime = &t
+		cr.Status.AtProvider.LastBackupLocation = location
+		cr.Status.AtProvider.LastBackupSizeBytes = size
+	}
+}
+
+func withPodSecurity(profile v1beta1.PodSecurityProfile) BackupModifier {
+	return func(cr *v1alpha1.PostgresBackup) {
+		cr.Status.AtProvider.PodSecurityProfile = string(profile)
+	}
+}
+
+func withConditions(conditions ...runtimev1alpha1.Condition) BackupModifier {
+	return func(cr *v1alpha1.PostgresBackup) {
+		cr.Status.Conditions = conditions
+	}
+}
+
+// Backup creates a v1alpha1 PostgresBackup for use in testing
+func Backup(m ...BackupModifier) *v1alpha1.PostgresBackup {
+	cr := &v1alpha1.PostgresBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: backupName},
+		Spec: v1alpha1.PostgresBackupSpec{
+			ForProvider: v1alpha1.PostgresBackupParameters{
+				PostgresName: utils.String(postgresName),
+				Schedule:     schedule,
+				Database:     utils.String("app"),
+				Destination: v1alpha1.PostgresBackupDestination{
+					PVC: &v1alpha1.PostgresBackupPVCDestination{ClaimName: "backups"},
+				},
+			},
+		},
+	}
+	for _, f := range m {
+		f(cr)
+	}
+	return cr
+}
+
+func instance() *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: postgresName, Namespace: postgres.DefaultNamespace}}
+}
+
+// mockGetInstance returns the Postgres instance from the control plane
+func mockGetInstance(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if o, ok := obj.(*v1alpha1.Postgres); ok {
+		o.Name = key.Name
+	}
+	return nil
+}
+
+// mockGetTarget returns the CronJob from the cluster the instance runs in, if
+// cj is not nil
+func mockGetTarget(cj *batchv1beta1.CronJob) test.MockGetFn {
+	return func(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+		switch o := obj.(type) {
+		case *batchv1beta1.CronJob:
+			if cj == nil {
+				return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+			}
+			cj.DeepCopyInto(o)
+		}
+		return nil
+	}
+}
+
+// mockListBackups lists a successful backup Job and its pod, which reports
+// the backup in its termination message
+func mockListBackups(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	switch l := list.(type) {
+	case *batchv1.JobList:
+		l.Items = []batchv1.Job{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly-backup-1"},
+				Status:     batchv1.JobStatus{Succeeded: 1, CompletionTime: &completed},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly-backup-2"},
+				Status:     batchv1.JobStatus{Failed: 1},
+			},
+		}
+	case *v1.PodList:
+		l.Items = []v1.Pod{{
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+				Name: postgres.BackupContainerName,
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message:  location + " 2048\n",
+				}},
+			}}},
+		}}
+	}
+	return nil
+}
+
+func cronJob(cr *v1alpha1.PostgresBackup) *batchv1beta1.CronJob {
+	cj, _ := postgres.MakeBackupCronJob(cr, instance())
+	return cj
+}
+
+// foreignCronJob returns the CronJob of the backup as another managed
+// resource created it
+func foreignCronJob(adopt bool) *batchv1beta1.CronJob {
+	cj := cronJob(Backup())
+	cj.ResourceVersion = "1"
+	cj.Labels[clients.LabelOwnerUID] = "other"
+	if adopt {
+		cj.Annotations = map[string]string{clients.AnnotationAdopt: "true"}
+	}
+	return cj
+}
+
+func TestObserve(t *testing.T) {
+
+	type want struct {
+		cr     resource.Managed
+		result managed.ExternalObservation
+		err    error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				cr:  unexpectedItem,
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"NoPostgres": {
+			args: args{
+				cr: &v1alpha1.PostgresBackup{},
+			},
+			want: want{
+				cr:  &v1alpha1.PostgresBackup{},
+				err: errors.New(errNoPostgres),
+			},
+		},
+		"GetCronJobError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:     Backup(),
+			},
+			want: want{
+				cr:  Backup(),
+				err: errors.Wrap(errBoom, errCronJobMsg),
+			},
+		},
+		"NotFound": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(nil)},
+				cr:     Backup(),
+			},
+			want: want{
+				cr:     Backup(),
+				result: managed.ExternalObservation{},
+			},
+		},
+		"UpToDate": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup())), MockList: mockListBackups},
+				cr:     Backup(),
+			},
+			want: want{
+				cr:     Backup(withLastBackup(completed, location, 2048), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+			},
+		},
+		"KeepNewerBackup": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup())), MockList: mockListBackups},
+				cr:     Backup(withLastBackup(metav1.NewTime(completed.Add(time.Hour)), "nightly/newer.dump", 4096)),
+			},
+			want: want{
+				cr: Backup(withLastBackup(metav1.NewTime(completed.Add(time.Hour)), "nightly/newer.dump", 4096),
+					withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+			},
+		},
+		"NotOwned": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(foreignCronJob(false)), MockList: mockListBackups},
+				cr:     Backup(),
+			},
+			want: want{
+				cr:     Backup(),
+				result: managed.ExternalObservation{ResourceExists: true},
+				err:    clients.CheckAdoption(foreignCronJob(false), cronJob(Backup())),
+			},
+		},
+		"Adopt": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(foreignCronJob(true)), MockList: mockListBackups},
+				cr:     Backup(),
+			},
+			want: want{
+				cr:     Backup(withLastBackup(completed, location, 2048), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false},
+			},
+		},
+		"PodSecurityRestricted": {
+			args: args{
+				kube:        &test.MockClient{MockGet: mockGetInstance},
+				target:      &test.MockClient{MockGet: mockGetTarget(cronJob(Backup())), MockList: mockListBackups},
+				podSecurity: v1beta1.PodSecurityProfileRestricted,
+				cr:          Backup(),
+			},
+			want: want{
+				cr: Backup(withPodSecurity(v1beta1.PodSecurityProfileRestricted), withLastBackup(completed, location, 2048),
+					withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false},
+			},
+		},
+		"PodSecurityUpToDate": {
+			args: args{
+				kube:        &test.MockClient{MockGet: mockGetInstance},
+				target:      &test.MockClient{MockGet: mockGetTarget(cronJob(Backup(withPodSecurity(v1beta1.PodSecurityProfileRestricted)))), MockList: mockListBackups},
+				podSecurity: v1beta1.PodSecurityProfileRestricted,
+				cr:          Backup(),
+			},
+			want: want{
+				cr: Backup(withPodSecurity(v1beta1.PodSecurityProfileRestricted), withLastBackup(completed, location, 2048),
+					withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+			},
+		},
+		"ScheduleChanged": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup())), MockList: mockListBackups},
+				cr:     Backup(withSchedule("0 * * * *")),
+			},
+			want: want{
+				cr: Backup(withSchedule("0 * * * *"), withLastBackup(completed, location, 2048),
+					withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := &external{
+				client:      tc.pg,
+				kube:        tc.kube,
+				target:      tc.target,
+				logger:      logging.NewNopLogger(),
+				podSecurity: tc.podSecurity,
+			}
+			o, err := e.Observe(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cr, tc.args.cr, test.EquateConditions()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	type want struct {
+		cronJob *batchv1beta1.CronJob
+		err     error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"NoInstance": {
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, postgresName))},
+				cr:   Backup(),
+			},
+			want: want{
+				err: errors.Wrap(kerrors.NewNotFound(schema.GroupResource{}, postgresName), errGetPostgresMsg),
+			},
+		},
+		"CreateError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(nil)},
+				pg: &fake.MockPostgresClient{
+					MockCreateOrUpdate: func(ctx context.Context, obj runtime.Object) (controllerutil.OperationResult, error) {
+						return controllerutil.OperationResultNone, errBoom
+					},
+				},
+				cr: Backup(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errCronJobCreateMsg),
+			},
+		},
+		"Successful": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(nil)},
+				pg:     &fake.MockPostgresClient{},
+				cr:     Backup(),
+			},
+			want: want{
+				cronJob: cronJob(Backup()),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var created *batchv1beta1.CronJob
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok && pg.MockCreateOrUpdate == nil {
+				pg.MockCreateOrUpdate = func(ctx context.Context, obj runtime.Object) (controllerutil.OperationResult, error) {
+					created = obj.(*batchv1beta1.CronJob)
+					return controllerutil.OperationResultCreated, nil
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			_, err := e.Create(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cronJob, created); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	type want struct {
+		cronJob *batchv1beta1.CronJob
+		err     error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"UpdateError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup()))},
+				pg: &fake.MockPostgresClient{
+					MockCreateOrUpdate: func(ctx context.Context, obj runtime.Object) (controllerutil.OperationResult, error) {
+						return controllerutil.OperationResultNone, errBoom
+					},
+				},
+				cr: Backup(withSchedule("0 4 * * *")),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errCronJobCreateMsg),
+			},
+		},
+		"ScheduleChanged": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup()))},
+				pg:     &fake.MockPostgresClient{},
+				cr:     Backup(withSchedule("0 4 * * *")),
+			},
+			want: want{
+				cronJob: cronJob(Backup(withSchedule("0 4 * * *"))),
+			},
+		},
+		"RetentionChanged": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup()))},
+				pg:     &fake.MockPostgresClient{},
+				cr:     Backup(withRetention(3)),
+			},
+			want: want{
+				cronJob: cronJob(Backup(withRetention(3))),
+			},
+		},
+		"DestinationChanged": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup()))},
+				pg:     &fake.MockPostgresClient{},
+				cr: Backup(withDestination(v1alpha1.PostgresBackupDestination{
+					PVC: &v1alpha1.PostgresBackupPVCDestination{ClaimName: "archive"},
+				})),
+			},
+			want: want{
+				cronJob: cronJob(Backup(withDestination(v1alpha1.PostgresBackupDestination{
+					PVC: &v1alpha1.PostgresBackupPVCDestination{ClaimName: "archive"},
+				}))),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var updated *batchv1beta1.CronJob
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok && pg.MockCreateOrUpdate == nil {
+				pg.MockCreateOrUpdate = func(ctx context.Context, obj runtime.Object) (controllerutil.OperationResult, error) {
+					updated = obj.(*batchv1beta1.CronJob)
+					return controllerutil.OperationResultUpdated, nil
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			_, err := e.Update(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cronJob, updated); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	type want struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"AlreadyGone": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(nil)},
+				cr:     Backup(),
+			},
+			want: want{},
+		},
+		"DeleteError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup())), MockDelete: test.NewMockDeleteFn(errBoom)},
+				cr:     Backup(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errDeleteMsg),
+			},
+		},
+		"Successful": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(cronJob(Backup())), MockDelete: test.NewMockDeleteFn(nil)},
+				cr:     Backup(),
+			},
+			want: want{},
+		},
+		"NotOwned": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(foreignCronJob(false)), MockDelete: test.NewMockDeleteFn(errBoom)},
+				cr:     Backup(),
+			},
+			want: want{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			err := e.Delete(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresdatabase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errUnexpectedObject = "the managed resource is not a PostgresDatabase resource" //nolint:golint
+	errNoPostgres       = "the postgres instance of the database is not set"
+	errGetPostgresMsg   = "failed to get postgres instance"                   //nolint:golint
+	errObserveMsg       = "failed to observe postgres database"               //nolint:golint
+	errCreateMsg        = "failed to create postgres database"                //nolint:golint
+	errUpdateMsg        = "failed to update postgres database"                //nolint:golint
+	errDeleteMsg        = "failed to drop postgres database"                  //nolint:golint
+	errExtensionsMsg    = "failed to update extensions of postgres database"  //nolint:golint
+	errObserveExtMsg    = "failed to observe extensions of postgres database" //nolint:golint
+	errUnexpectedRow    = "unexpected row describing postgres database: %q"
+
+	// maintenanceDatabase is the database the statements managing databases
+	// are run in
+	maintenanceDatabase = "postgres"
+)
+
+// SetupPostgresDatabase adds a controller that reconciles PostgresDatabases.
+func SetupPostgresDatabase(mgr ctrl.Manager, l logging.Logger) error {
+	name := managed.ControllerName(v1alpha1.PostgresDatabaseGroupKind)
+	logger := l.WithValues("controller", name)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1alpha1.PostgresDatabase{}).
+		Complete(managed.NewReconciler(mgr,
+			resource.ManagedKind(v1alpha1.PostgresDatabaseGroupVersionKind),
+			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: logger}),
+			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLogger(logger),
+			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+type connector struct {
+	kube        client.Client
+	newClientFn func(kube client.Client, cs kubernetes.Interface, rc *rest.Config) postgres.Client
+	logger      logging.Logger
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.PostgresDatabase)
+	if !ok {
+		return nil, errors.New(errUnexpectedObject)
+	}
+
+	c.logger.Debug("Connecting")
+
+	rc, err := clients.GetProviderConfigRC(ctx, cr, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	kube, err := client.New(rc, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &external{client: c.newClientFn(kube, cs, rc), kube: c.kube, logger: c.logger}, nil
+}
+
+// external manages databases inside an instance. kube is the client of the
+// cluster the managed resources live in, the instance itself is reached
+// through client.
+type external struct {
+	client postgres.Client
+	kube   client.Client
+	logger logging.Logger
+}
+
+// observedDatabase is the state of a database as read from the instance
+type observedDatabase struct {
+	owner      string
+	connLimit  int
+	allowConns bool
+}
+
+// observeDatabaseQuery describes the database with the given name in a single
+// row
+const observeDatabaseQuery = `SELECT pg_get_userbyid(d.datdba), d.datconnlimit, d.datallowconn FROM pg_database d WHERE d.datname = %s`
+
+func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresDatabase)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	observed, err := e.observe(ctx, ps, meta.GetExternalName(cr))
+	if err != nil || observed == nil {
+		return managed.ExternalObservation{}, err
+	}
+	if err := postgres.ValidateExtensions(cr.Spec.ForProvider.Extensions); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	extensionsUpToDate, err := e.observeExtensions(ctx, cr, ps, observed)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	cr.SetConditions(runtimev1alpha1.Available())
+
+	return managed.ExternalObservation{
+		ResourceExists:    true,
+		ResourceUpToDate:  isUpToDate(cr, observed) && extensionsUpToDate,
+		ConnectionDetails: connectionDetails(cr, ps),
+	}, nil
+}
+
+// instance fetches the Postgres instance the database is created in
+func (e *external) instance(ctx context.Context, cr *v1alpha1.PostgresDatabase) (*v1alpha1.Postgres, error) {
+	if cr.Spec.ForProvider.PostgresName == nil {
+		return nil, errors.New(errNoPostgres)
+	}
+	ps, err := postgres.GetInstance(ctx, e.kube, *cr.Spec.ForProvider.PostgresName)
+	return ps, errors.Wrap(err, errGetPostgresMsg)
+}
+
+// observe reads the database with the given name from the instance. It
+// returns nil if the database does not exist.
+func (e *external) observe(ctx context.Context, ps *v1alpha1.Postgres, name string) (*observedDatabase, error) {
+	out, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, fmt.Sprintf(observeDatabaseQuery, postgres.QuoteLiteral(name)))
+	if err != nil {
+		return nil, errors.Wrap(err, errObserveMsg)
+	}
+	rows := postgres.SplitRows(out)
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+	if len(row) != 3 {
+		return nil, errors.Errorf(errUnexpectedRow, strings.Join(row, "|"))
+	}
+	connLimit, err := strconv.Atoi(row[1])
+	if err != nil {
+		return nil, errors.Errorf(errUnexpectedRow, strings.Join(row, "|"))
+	}
+	return &observedDatabase{owner: row[0], connLimit: connLimit, allowConns: row[2] == "t"}, nil
+}
+
+// isUpToDate compares the mutable settings of the observed database against
+// the spec. Without an owner in the spec any owner is accepted.
+func isUpToDate(cr *v1alpha1.PostgresDatabase, o *observedDatabase) bool {
+	p := cr.Spec.ForProvider
+	return (p.Owner == nil || *p.Owner == o.owner) &&
+		connectionLimit(p.ConnectionLimit) == o.connLimit &&
+		utils.BoolValueFallback(p.AllowConnections, true) == o.allowConns
+}
+
+// installedExtensions reads the extensions installed in the database
+func (e *external) installedExtensions(ctx context.Context, cr *v1alpha1.PostgresDatabase, ps *v1alpha1.Postgres) (map[string]string, error) {
+	out, err := e.client.ExecSQL(ctx, ps, meta.GetExternalName(cr), postgres.ObserveExtensionsQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, errObserveExtMsg)
+	}
+	installed, err := postgres.ParseExtensions(out)
+	return installed, errors.Wrap(err, errObserveExtMsg)
+}
+
+// observeExtensions records the versions of the extensions installed in the
+// database and checks whether they match the spec. A database which does not
+// allow connections cannot be inspected, its extensions are not compared.
+func (e *external) observeExtensions(ctx context.Context, cr *v1alpha1.PostgresDatabase, ps *v1alpha1.Postgres, o *observedDatabase) (bool, error) {
+	p, s := cr.Spec.ForProvider, &cr.Status.AtProvider
+	if (len(p.Extensions) == 0 && len(s.Extensions) == 0) || !o.allowConns {
+		return true, nil
+	}
+	installed, err := e.installedExtensions(ctx, cr, ps)
+	if err != nil {
+		return false, err
+	}
+	stmts := postgres.ExtensionStatements(p.Extensions, s.Extensions, installed)
+	s.Extensions = postgres.ExtensionStatus(p.Extensions, s.Extensions, installed)
+	return len(stmts) == 0, nil
+}
+
+// updateExtensions creates, updates and drops the extensions in the database
+func (e *external) updateExtensions(ctx context.Context, cr *v1alpha1.PostgresDatabase, ps *v1alpha1.Postgres) error {
+	p, s := cr.Spec.ForProvider, cr.Status.AtProvider
+	if (len(p.Extensions) == 0 && len(s.Extensions) == 0) || !utils.BoolValueFallback(p.AllowConnections, true) {
+		return nil
+	}
+	installed, err := e.installedExtensions(ctx, cr, ps)
+	if err != nil {
+		return err
+	}
+	stmts := postgres.ExtensionStatements(p.Extensions, s.Extensions, installed)
+	if len(stmts) == 0 {
+		return nil
+	}
+	_, err = e.client.ExecSQL(ctx, ps, meta.GetExternalName(cr), stmts...)
+	return errors.Wrap(err, errExtensionsMsg)
+}
+
+func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresDatabase)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+
+	p := cr.Spec.ForProvider
+	options := []string{}
+	if p.Owner != nil {
+		options = append(options, "OWNER "+postgres.QuoteIdentifier(*p.Owner))
+	}
+	if p.Template != nil {
+		options = append(options, "TEMPLATE "+postgres.QuoteIdentifier(*p.Template))
+	}
+	if p.Encoding != nil {
+		options = append(options, "ENCODING "+postgres.QuoteLiteral(*p.Encoding))
+	}
+	if p.LCCollate != nil {
+		options = append(options, "LC_COLLATE "+postgres.QuoteLiteral(*p.LCCollate))
+	}
+	if p.LCCType != nil {
+		options = append(options, "LC_CTYPE "+postgres.QuoteLiteral(*p.LCCType))
+	}
+	options = append(options, settings(cr))
+
+	stmt := fmt.Sprintf("CREATE DATABASE %s WITH %s", postgres.QuoteIdentifier(meta.GetExternalName(cr)), strings.Join(options, " "))
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, stmt); err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreateMsg)
+	}
+	if len(p.Extensions) > 0 && utils.BoolValueFallback(p.AllowConnections, true) {
+		stmts := postgres.ExtensionStatements(p.Extensions, nil, nil)
+		if _, err := e.client.ExecSQL(ctx, ps, meta.GetExternalName(cr), stmts...); err != nil {
+			return managed.ExternalCreation{}, errors.Wrap(err, errExtensionsMsg)
+		}
+	}
+	return managed.ExternalCreation{ConnectionDetails: connectionDetails(cr, ps)}, nil
+}
+
+func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresDatabase)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+
+	name := postgres.QuoteIdentifier(meta.GetExternalName(cr))
+	statements := []string{fmt.Sprintf("ALTER DATABASE %s WITH %s", name, settings(cr))}
+	if cr.Spec.ForProvider.Owner != nil {
+		statements = append(statements, fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", name, postgres.QuoteIdentifier(*cr.Spec.ForProvider.Owner)))
+	}
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, statements...); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateMsg)
+	}
+	return managed.ExternalUpdate{}, e.updateExtensions(ctx, cr, ps)
+}
+
+func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
+	cr, ok := mgd.(*v1alpha1.PostgresDatabase)
+	if !ok {
+		return errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return err
+	}
+	observed, err := e.observe(ctx, ps, meta.GetExternalName(cr))
+	if err != nil || observed == nil {
+		return err
+	}
+	// a database cannot be dropped while clients are connected to it
+	name := meta.GetExternalName(cr)
+	_, err = e.client.ExecSQL(ctx, ps, maintenanceDatabase,
+		fmt.Sprintf("ALTER DATABASE %s WITH ALLOW_CONNECTIONS false", postgres.QuoteIdentifier(name)),
+		fmt.Sprintf("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = %s AND pid <> pg_backend_pid()", postgres.QuoteLiteral(name)),
+		fmt.Sprintf("DROP DATABASE IF EXISTS %s", postgres.QuoteIdentifier(name)))
+	return errors.Wrap(err, errDeleteMsg)
+}
+
+// connectionDetails returns the address of the database
+func connectionDetails(cr *v1alpha1.PostgresDatabase, ps *v1alpha1.Postgres) managed.ConnectionDetails {
+	return managed.ConnectionDetails{
+		runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(postgres.PrimaryHost(ps)),
+		runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte(strconv.Itoa(postgres.Port(ps))),
+		postgres.ResourceCredentialsSecretDatabaseKey:        []byte(meta.GetExternalName(cr)),
+	}
+}
+
+// settings renders the mutable settings of the spec
+func settings(cr *v1alpha1.PostgresDatabase) string {
+	return fmt.Sprintf("CONNECTION LIMIT %d ALLOW_CONNECTIONS %t",
+		connectionLimit(cr.Spec.ForProvider.ConnectionLimit), utils.BoolValueFallback(cr.Spec.ForProvider.AllowConnections, true))
+}
+
+func connectionLimit(limit *int) int {
+	if limit == nil {
+		return -1
+	}
+	return *limit
+}
@@ -0,0 +1,571 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresdatabase
+
+import (
+	"context"
+	"testing"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres/fake"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+var (
+	// an arbitrary managed resource
+	unexpectedItem resource.Managed
+	errBoom        = errors.New("boom")
+
+	databaseName = "app"
+	postgresName = "postgresdb"
+	endpoint     = "postgresdb.default.svc"
+	owner        = "app"
+)
+
+type args struct {
+	pg   postgres.Client
+	kube client.Client
+	cr   resource.Managed
+}
+
+// DatabaseModifier is a function which modifies the PostgresDatabase for
+// testing
+type DatabaseModifier func(cr *v1alpha1.PostgresDatabase)
+
+func withOwner(o string) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Spec.ForProvider.Owner = &o
+	}
+}
+
+func withConnectionLimit(l int) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Spec.ForProvider.ConnectionLimit = &l
+	}
+}
+
+func withAllowConnections(a bool) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Spec.ForProvider.AllowConnections = &a
+	}
+}
+
+func withEncoding(e string) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Spec.ForProvider.Encoding = &e
+	}
+}
+
+func withExtensions(exts ...v1alpha1.PostgresExtension) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Spec.ForProvider.Extensions = exts
+	}
+}
+
+func withExtensionStatus(exts ...v1alpha1.PostgresExtensionStatus) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Status.AtProvider.Extensions = exts
+	}
+}
+
+func withConditions(conditions ...runtimev1alpha1.Condition) DatabaseModifier {
+	return func(cr *v1alpha1.PostgresDatabase) {
+		cr.Status.Conditions = conditions
+	}
+}
+
+// Database creates a v1alpha1 PostgresDatabase for use in testing
+func Database(m ...DatabaseModifier) *v1alpha1.PostgresDatabase {
+	cr := &v1alpha1.PostgresDatabase{
+		Spec: v1alpha1.PostgresDatabaseSpec{
+			ForProvider: v1alpha1.PostgresDatabaseParameters{
+				PostgresName: utils.String(postgresName),
+			},
+		},
+	}
+	for _, f := range m {
+		f(cr)
+	}
+	meta.SetExternalName(cr, databaseName)
+	return cr
+}
+
+func mockGet(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if o, ok := obj.(*v1alpha1.Postgres); ok {
+		o.Name = key.Name
+	}
+	return nil
+}
+
+// mockExecSQL answers the first query with the observed row and records all
+// following statements
+func mockExecSQL(observed string, executed *[]string) func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+	return func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+		if len(*executed) == 0 {
+			*executed = append(*executed, "<observe>")
+			return observed, nil
+		}
+		*executed = append(*executed, statements...)
+		return "", nil
+	}
+}
+
+func connectionDetailsFixture() managed.ConnectionDetails {
+	return managed.ConnectionDetails{
+		runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+		runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+		postgres.ResourceCredentialsSecretDatabaseKey:        []byte(databaseName),
+	}
+}
+
+func TestObserve(t *testing.T) {
+
+	type want struct {
+		cr     resource.Managed
+		result managed.ExternalObservation
+		err    error
+	}
+
+	cases := map[string]struct {
+		args
+		observed string
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				cr:  unexpectedItem,
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"NoPostgres": {
+			args: args{
+				cr: &v1alpha1.PostgresDatabase{},
+			},
+			want: want{
+				cr:  &v1alpha1.PostgresDatabase{},
+				err: errors.New(errNoPostgres),
+			},
+		},
+		"ExecError": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+						return "", errBoom
+					},
+				},
+				cr: Database(),
+			},
+			want: want{
+				cr:  Database(),
+				err: errors.Wrap(errBoom, errObserveMsg),
+			},
+		},
+		"NotFound": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(),
+			},
+			want: want{
+				cr:     Database(),
+				result: managed.ExternalObservation{},
+			},
+		},
+		"UpToDate": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withOwner(owner)),
+			},
+			observed: owner + "|-1|t\n",
+			want: want{
+				cr:     Database(withOwner(owner), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: connectionDetailsFixture()},
+			},
+		},
+		"OwnerChanged": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withOwner("other")),
+			},
+			observed: owner + "|-1|t\n",
+			want: want{
+				cr:     Database(withOwner("other"), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false, ConnectionDetails: connectionDetailsFixture()},
+			},
+		},
+		"ConnectionLimitChanged": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withConnectionLimit(10)),
+			},
+			observed: owner + "|-1|t\n",
+			want: want{
+				cr:     Database(withConnectionLimit(10), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false, ConnectionDetails: connectionDetailsFixture()},
+			},
+		},
+		"ExtensionsInstalled": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+						if database == databaseName && statements[0] == postgres.ObserveExtensionsQuery {
+							return "pgcrypto|1.3\nplpgsql|1.0\n", nil
+						}
+						return owner + "|-1|t\n", nil
+					},
+				},
+				cr: Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"})),
+			},
+			want: want{
+				cr: Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"}),
+					withExtensionStatus(v1alpha1.PostgresExtensionStatus{Name: "pgcrypto", Version: "1.3"}),
+					withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: connectionDetailsFixture()},
+			},
+		},
+		"ExtensionMissing": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"})),
+			},
+			observed: owner + "|-1|t\n",
+			want: want{
+				cr:     Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"}), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false, ConnectionDetails: connectionDetailsFixture()},
+			},
+		},
+		"ExtensionsNoConnections": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"}), withAllowConnections(false)),
+			},
+			observed: owner + "|-1|f\n",
+			want: want{
+				cr:     Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"}), withAllowConnections(false), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: connectionDetailsFixture()},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok && pg.MockExecSQL == nil {
+				pg.MockExecSQL = mockExecSQL(tc.observed, &executed)
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			o, err := e.Observe(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cr, tc.args.cr, test.EquateConditions()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	type want struct {
+		result     managed.ExternalCreation
+		statements []string
+		err        error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"GetPostgresError": {
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:   Database(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetPostgresMsg),
+			},
+		},
+		"Defaults": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(),
+			},
+			want: want{
+				result: managed.ExternalCreation{ConnectionDetails: connectionDetailsFixture()},
+				statements: []string{
+					`CREATE DATABASE "app" WITH CONNECTION LIMIT -1 ALLOW_CONNECTIONS true`,
+				},
+			},
+		},
+		"WithOptions": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withOwner(owner), withEncoding("UTF8"), withConnectionLimit(10)),
+			},
+			want: want{
+				result: managed.ExternalCreation{ConnectionDetails: connectionDetailsFixture()},
+				statements: []string{
+					`CREATE DATABASE "app" WITH OWNER "app" ENCODING 'UTF8' CONNECTION LIMIT 10 ALLOW_CONNECTIONS true`,
+				},
+			},
+		},
+		"WithExtensions": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"}, v1alpha1.PostgresExtension{Name: "postgis", Version: utils.String("3.0.2")})),
+			},
+			want: want{
+				result: managed.ExternalCreation{ConnectionDetails: connectionDetailsFixture()},
+				statements: []string{
+					`CREATE DATABASE "app" WITH CONNECTION LIMIT -1 ALLOW_CONNECTIONS true`,
+					`CREATE EXTENSION IF NOT EXISTS "pgcrypto" CASCADE`,
+					`CREATE EXTENSION IF NOT EXISTS "postgis" VERSION '3.0.2' CASCADE`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			executed := []string{}
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok {
+				pg.MockExecSQL = func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+					executed = append(executed, statements...)
+					return "", nil
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			o, err := e.Create(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if tc.want.statements != nil {
+				if diff := cmp.Diff(tc.want.statements, executed); diff != "" {
+					t.Errorf("r: -want, +got:\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	type want struct {
+		statements []string
+		err        error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"AlterSettings": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withConnectionLimit(10)),
+			},
+			want: want{
+				statements: []string{
+					`ALTER DATABASE "app" WITH CONNECTION LIMIT 10 ALLOW_CONNECTIONS true`,
+				},
+			},
+		},
+		"AlterOwner": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(withOwner("other")),
+			},
+			want: want{
+				statements: []string{
+					`ALTER DATABASE "app" WITH CONNECTION LIMIT -1 ALLOW_CONNECTIONS true`,
+					`ALTER DATABASE "app" OWNER TO "other"`,
+				},
+			},
+		},
+		"UpdateExtensions": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr: Database(withExtensions(v1alpha1.PostgresExtension{Name: "pgcrypto"}),
+					withExtensionStatus(v1alpha1.PostgresExtensionStatus{Name: "hstore", Version: "1.6"})),
+			},
+			want: want{
+				statements: []string{
+					`ALTER DATABASE "app" WITH CONNECTION LIMIT -1 ALLOW_CONNECTIONS true`,
+					postgres.ObserveExtensionsQuery,
+					`CREATE EXTENSION IF NOT EXISTS "pgcrypto" CASCADE`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok {
+				pg.MockExecSQL = func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+					executed = append(executed, statements...)
+					return "", nil
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			_, err := e.Update(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.statements, executed); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	type want struct {
+		statements []string
+		err        error
+	}
+
+	cases := map[string]struct {
+		args
+		observed string
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"AlreadyGone": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(),
+			},
+			want: want{
+				statements: []string{`<observe>`},
+			},
+		},
+		"Drop": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Database(),
+			},
+			observed: owner + "|-1|t\n",
+			want: want{
+				statements: []string{
+					`<observe>`,
+					`ALTER DATABASE "app" WITH ALLOW_CONNECTIONS false`,
+					`SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = 'app' AND pid <> pg_backend_pid()`,
+					`DROP DATABASE IF EXISTS "app"`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok {
+				pg.MockExecSQL = mockExecSQL(tc.observed, &executed)
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			err := e.Delete(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.statements, executed); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
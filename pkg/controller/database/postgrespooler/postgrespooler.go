@@ -0,0 +1,368 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgrespooler
+
+import (
+	"context"
+	"strconv"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/apis/v1beta1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errUnexpectedObject   = "the managed resource is not a PostgresPooler resource" //nolint:golint
+	errNoPostgres         = "the postgres instance of the pooler is not set"
+	errGetPostgresMsg     = "failed to get postgres instance"                       //nolint:golint
+	errUserlistMsg        = "failed to list the roles of the postgres instance"     //nolint:golint
+	errDeploymentMsg      = "failed to get pooler deployment"                       //nolint:golint
+	errSecretMsg          = "failed to get pooler secret"                           //nolint:golint
+	errServiceMsg         = "failed to get pooler service"                          //nolint:golint
+	errPasswordMsg        = "failed to get the master password of the instance"     //nolint:golint
+	errTLSSecretMsg       = "failed to get the TLS secret of the instance"          //nolint:golint
+	errSecretApplyMsg     = "failed to create or update pooler secret"              //nolint:golint
+	errDeploymentApplyMsg = "failed to create or update pooler deployment"          //nolint:golint
+	errServiceApplyMsg    = "failed to create or update pooler service"             //nolint:golint
+	errDeleteMsg          = "failed to delete pooler deployment, service or secret" //nolint:golint
+	errPodsMsg            = "failed to list pooler pods"                            //nolint:golint
+	errReloadMsg          = "failed to reload pooler configuration"                 //nolint:golint
+
+	maintenanceDatabase = "postgres"
+)
+
+// SetupPostgresPooler adds a controller that reconciles PostgresPoolers.
+func SetupPostgresPooler(mgr ctrl.Manager, l logging.Logger) error {
+	name := managed.ControllerName(v1alpha1.PostgresPoolerGroupKind)
+	logger := l.WithValues("controller", name)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1alpha1.PostgresPooler{}).
+		Complete(managed.NewReconciler(mgr,
+			resource.ManagedKind(v1alpha1.PostgresPoolerGroupVersionKind),
+			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: logger}),
+			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLogger(logger),
+			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+type connector struct {
+	kube        client.Client
+	newClientFn func(kube client.Client, cs kubernetes.Interface, rc *rest.Config) postgres.Client
+	logger      logging.Logger
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.PostgresPooler)
+	if !ok {
+		return nil, errors.New(errUnexpectedObject)
+	}
+
+	c.logger.Debug("Connecting")
+
+	pc, err := clients.GetProviderConfig(ctx, cr, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := clients.ProviderConfigRC(ctx, pc, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	kube, err := client.New(rc, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &external{client: c.newClientFn(kube, cs, rc), kube: c.kube, target: kube, logger: c.logger, podSecurity: clients.PodSecurityProfile(pc)}, nil
+}
+
+// external manages the PgBouncer Deployment, Service and Secret of a pooler.
+// kube is the client of the cluster the managed resources live in, target
+// the client of the cluster the instance runs in.
+type external struct {
+	client      postgres.Client
+	kube        client.Client
+	target      client.Client
+	logger      logging.Logger
+	podSecurity v1beta1.PodSecurityProfile
+}
+
+func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.ExternalObservation, error) { //nolint:gocyclo
+	cr, ok := mgd.(*v1alpha1.PostgresPooler)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errUnexpectedObject)
+	}
+	if meta.WasDeleted(cr) {
+		return e.observeDeleted(ctx, cr)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	depl := &appsv1.Deployment{}
+	err = e.target.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: ps.Namespace}, depl)
+	if kerrors.IsNotFound(err) {
+		return managed.ExternalObservation{}, nil
+	}
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errDeploymentMsg)
+	}
+	cr.Status.AtProvider.ReadyReplicas = depl.Status.ReadyReplicas
+	if depl.Status.ReadyReplicas > 0 {
+		cr.SetConditions(runtimev1alpha1.Available())
+	} else {
+		cr.SetConditions(runtimev1alpha1.Creating())
+	}
+
+	secret, err := e.poolerSecret(ctx, cr, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	desired := postgres.MakePoolerDeployment(cr, ps, secret)
+	owned, err := clients.ObserveOwner(depl, desired)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+	upToDate := owned && postgres.IsDeploymentUpToDate(desired, depl)
+
+	if upToDate {
+		s := &v1.Secret{}
+		err := e.target.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, s)
+		if resource.IgnoreNotFound(err) != nil {
+			return managed.ExternalObservation{ResourceExists: true}, errors.Wrap(err, errSecretMsg)
+		}
+		upToDate = err == nil && postgres.IsPoolerSecretUpToDate(secret, s) &&
+			cr.Status.AtProvider.ConfigChecksum == postgres.PoolerChecksum(secret)
+	}
+	if upToDate {
+		svc := &v1.Service{}
+		desired := postgres.MakePoolerService(cr)
+		err := e.target.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, svc)
+		if resource.IgnoreNotFound(err) != nil {
+			return managed.ExternalObservation{ResourceExists: true}, errors.Wrap(err, errServiceMsg)
+		}
+		upToDate = err == nil && postgres.IsServiceUpToDate(desired, svc)
+	}
+
+	details, err := e.connectionDetails(ctx, cr, ps)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: upToDate}, err
+	}
+	return managed.ExternalObservation{
+		ResourceExists:    true,
+		ResourceUpToDate:  upToDate,
+		ConnectionDetails: details,
+	}, nil
+}
+
+// observeDeleted checks whether the Deployment of a deleted pooler still
+// exists without looking up its instance, which may be gone already
+func (e *external) observeDeleted(ctx context.Context, cr *v1alpha1.PostgresPooler) (managed.ExternalObservation, error) {
+	if cr.Status.AtProvider.Namespace == "" {
+		return managed.ExternalObservation{}, nil
+	}
+	depl := &appsv1.Deployment{}
+	err := e.target.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: cr.Status.AtProvider.Namespace}, depl)
+	if kerrors.IsNotFound(err) {
+		return managed.ExternalObservation{}, nil
+	}
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errDeploymentMsg)
+	}
+	return managed.ExternalObservation{ResourceExists: clients.IsOwnedBy(depl, cr), ResourceUpToDate: true}, nil
+}
+
+// instance fetches the Postgres instance the pooler connects to and records
+// its namespace and the security profile the pods of the pooler are rendered
+// with in the status of the pooler
+func (e *external) instance(ctx context.Context, cr *v1alpha1.PostgresPooler) (*v1alpha1.Postgres, error) {
+	if cr.Spec.ForProvider.PostgresName == nil {
+		return nil, errors.New(errNoPostgres)
+	}
+	ps, err := postgres.GetInstance(ctx, e.kube, *cr.Spec.ForProvider.PostgresName)
+	if err != nil {
+		return nil, errors.Wrap(err, errGetPostgresMsg)
+	}
+	cr.Status.AtProvider.Namespace = ps.Namespace
+	cr.Status.AtProvider.PodSecurityProfile = string(e.podSecurity)
+	return ps, nil
+}
+
+// poolerSecret lists the login roles of the instance and returns the Secret
+// holding the pgbouncer.ini and the userlist of the pooler
+func (e *external) poolerSecret(ctx context.Context, cr *v1alpha1.PostgresPooler, ps *v1alpha1.Postgres) (*v1.Secret, error) {
+	out, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, postgres.UserlistQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, errUserlistMsg)
+	}
+	userlist, users, err := postgres.MakeUserlist(out)
+	if err != nil {
+		return nil, errors.Wrap(err, errUserlistMsg)
+	}
+	cr.Status.AtProvider.Users = users
+	return postgres.MakePoolerSecret(cr, ps, userlist), nil
+}
+
+// connectionDetails returns the connection details of the master user of the
+// instance through the Service of the pooler. They only differ from the
+// details of the instance in the host and port, so that the pooler can
+// replace the instance in the connection secret of an application.
+func (e *external) connectionDetails(ctx context.Context, cr *v1alpha1.PostgresPooler, ps *v1alpha1.Postgres) (managed.ConnectionDetails, error) {
+	s := &v1.Secret{}
+	if err := e.target.Get(ctx, types.NamespacedName{Name: postgres.PasswordSecretName(ps), Namespace: ps.Namespace}, s); err != nil {
+		return nil, errors.Wrap(err, errPasswordMsg)
+	}
+	host := postgres.ServiceHost(cr.Name, ps.Namespace)
+	port := strconv.Itoa(postgres.PoolerPort(cr))
+	details := managed.ConnectionDetails{
+		runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(host),
+		runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte(port),
+		runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(utils.StringValue(ps.Spec.ForProvider.MasterUsername)),
+		runtimev1alpha1.ResourceCredentialsSecretPasswordKey: s.Data[postgres.PasswordSecretKey],
+		postgres.ResourceCredentialsSecretDatabaseKey:        []byte(utils.StringValue(ps.Spec.ForProvider.Database)),
+		postgres.ResourceCredentialsSecretHostKey:            []byte(host),
+	}
+	if ps.Spec.ForProvider.TLS != nil {
+		tls := &v1.Secret{}
+		if err := e.target.Get(ctx, types.NamespacedName{Name: postgres.TLSSecretName(ps), Namespace: ps.Namespace}, tls); err != nil {
+			return nil, errors.Wrap(err, errTLSSecretMsg)
+		}
+		details = postgres.AddTLSConnectionDetails(details, ps, tls)
+	}
+	return postgres.CompleteConnectionDetails(ps, details, host, port)
+}
+
+// apply creates or updates the Secret, the Deployment and the Service of the
+// pooler and returns the Secret. The Secret comes first, the pods mount it.
+func (e *external) apply(ctx context.Context, cr *v1alpha1.PostgresPooler) (*v1.Secret, error) {
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := e.poolerSecret(ctx, cr, ps)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, secret); err != nil {
+		return nil, errors.Wrap(err, errSecretApplyMsg)
+	}
+	if _, err := e.client.CreateOrUpdate(ctx, postgres.MakePoolerDeployment(cr, ps, secret)); err != nil {
+		return nil, errors.Wrap(err, errDeploymentApplyMsg)
+	}
+	_, err = e.client.CreateOrUpdate(ctx, postgres.MakePoolerService(cr))
+	return secret, errors.Wrap(err, errServiceApplyMsg)
+}
+
+// reload makes the ready pods of the pooler reread the pgbouncer.ini and the
+// userlist of the given Secret, so that a new role or a changed pool size
+// does not restart PgBouncer and drop the connections of its clients. The
+// checksum of the Secret is recorded once no pod sees outdated files, until
+// then reload is retried by every Update.
+func (e *external) reload(ctx context.Context, cr *v1alpha1.PostgresPooler, secret *v1.Secret) error {
+	checksum := postgres.PoolerChecksum(secret)
+	if cr.Status.AtProvider.ConfigChecksum == checksum {
+		return nil
+	}
+	pods := &v1.PodList{}
+	if err := e.target.List(ctx, pods, client.InNamespace(secret.Namespace), client.MatchingLabels{postgres.LabelPooler: cr.Name}); err != nil {
+		return errors.Wrap(err, errPodsMsg)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// pods which are not ready read the files when they start
+		if pod.DeletionTimestamp != nil || !postgres.IsPodReady(pod) {
+			continue
+		}
+		out, err := e.client.ExecInPod(ctx, pod, postgres.PgBouncerContainerName, postgres.ReloadPoolerCommand(checksum))
+		if err != nil {
+			return errors.Wrap(err, errReloadMsg)
+		}
+		if postgres.IsConfigStale(out) {
+			return nil
+		}
+	}
+	cr.Status.AtProvider.ConfigChecksum = checksum
+	return nil
+}
+
+func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresPooler)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
+	}
+	cr.SetConditions(runtimev1alpha1.Creating())
+	secret, err := e.apply(ctx, cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	// the pods of a new pooler read the files when they start
+	cr.Status.AtProvider.ConfigChecksum = postgres.PoolerChecksum(secret)
+	return managed.ExternalCreation{}, nil
+}
+
+// Update converges the pooler to its spec and the userlist to the login
+// roles of the instance
+func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresPooler)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
+	}
+	secret, err := e.apply(ctx, cr)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	return managed.ExternalUpdate{}, e.reload(ctx, cr, secret)
+}
+
+// Delete removes the objects of the pooler in the namespace recorded in its
+// status, so that a pooler can be deleted after its instance
+func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
+	cr, ok := mgd.(*v1alpha1.PostgresPooler)
+	if !ok {
+		return errors.New(errUnexpectedObject)
+	}
+	if cr.Status.AtProvider.Namespace == "" {
+		return nil
+	}
+	return errors.Wrap(e.client.DeletePostgresPooler(ctx, cr), errDeleteMsg)
+}
@@ -0,0 +1,483 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresrole
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+const (
+	errUnexpectedObject    = "the managed resource is not a PostgresRole resource" //nolint:golint
+	errNoPostgres          = "the postgres instance of the role is not set"
+	errGetPostgresMsg      = "failed to get postgres instance"                //nolint:golint
+	errObserveMsg          = "failed to observe postgres role"                //nolint:golint
+	errCreateMsg           = "failed to create postgres role"                 //nolint:golint
+	errUpdateMsg           = "failed to update postgres role"                 //nolint:golint
+	errDeleteMsg           = "failed to drop postgres role"                   //nolint:golint
+	errPasswordSecretMsg   = "failed to get password secret of postgres role" //nolint:golint
+	errGeneratePasswordMsg = "failed to generate postgres role password"      //nolint:golint
+	errUnexpectedRow       = "unexpected row describing postgres role: %q"
+	errRoleNotFound        = "postgres role %s does not exist"
+
+	// maintenanceDatabase is the database the statements managing roles are
+	// run in
+	maintenanceDatabase = "postgres"
+)
+
+// SetupPostgresRole adds a controller that reconciles PostgresRoles.
+func SetupPostgresRole(mgr ctrl.Manager, l logging.Logger) error {
+	name := managed.ControllerName(v1alpha1.PostgresRoleGroupKind)
+	logger := l.WithValues("controller", name)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1alpha1.PostgresRole{}).
+		Complete(managed.NewReconciler(mgr,
+			resource.ManagedKind(v1alpha1.PostgresRoleGroupVersionKind),
+			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: logger}),
+			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLogger(logger),
+			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+type connector struct {
+	kube        client.Client
+	newClientFn func(kube client.Client, cs kubernetes.Interface, rc *rest.Config) postgres.Client
+	logger      logging.Logger
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.PostgresRole)
+	if !ok {
+		return nil, errors.New(errUnexpectedObject)
+	}
+
+	c.logger.Debug("Connecting")
+
+	rc, err := clients.GetProviderConfigRC(ctx, cr, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	kube, err := client.New(rc, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &external{client: c.newClientFn(kube, cs, rc), kube: c.kube, logger: c.logger}, nil
+}
+
+// external manages roles inside an instance. kube is the client of the
+// cluster the managed resources live in, the instance itself is reached
+// through client.
+type external struct {
+	client postgres.Client
+	kube   client.Client
+	logger logging.Logger
+}
+
+// observedRole is the state of a role as read from the instance
+type observedRole struct {
+	login      bool
+	createDB   bool
+	createRole bool
+	inherit    bool
+	connLimit  int
+	password   string
+	memberOf   []string
+	grants     map[string][]string
+}
+
+// observeRoleQuery describes the role with the given name in a single row.
+// Privileges on databases owned by the role are implicit and left out.
+const observeRoleQuery = `SELECT r.rolcanlogin, r.rolcreatedb, r.rolcreaterole, r.rolinherit, r.rolconnlimit,
+  COALESCE(r.rolpassword, ''),
+  COALESCE((SELECT string_agg(b.rolname, ',' ORDER BY b.rolname) FROM pg_auth_members m JOIN pg_roles b ON b.oid = m.roleid WHERE m.member = r.oid), ''),
+  COALESCE((SELECT string_agg(d.datname || ':' || a.privilege_type, ',' ORDER BY d.datname, a.privilege_type)
+    FROM pg_database d, aclexplode(d.datacl) a WHERE a.grantee = r.oid AND d.datdba <> r.oid), '')
+FROM pg_authid r WHERE r.rolname = %s`
+
+func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresRole)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	observed, err := e.observe(ctx, ps, meta.GetExternalName(cr))
+	if err != nil || observed == nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	password, err := e.password(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	cr.SetConditions(runtimev1alpha1.Available())
+
+	details := connectionDetails(cr, ps, password)
+	return managed.ExternalObservation{
+		ResourceExists:    true,
+		ResourceUpToDate:  isUpToDate(cr, observed, password),
+		ConnectionDetails: details,
+	}, nil
+}
+
+// instance fetches the Postgres instance the role is created in
+func (e *external) instance(ctx context.Context, cr *v1alpha1.PostgresRole) (*v1alpha1.Postgres, error) {
+	if cr.Spec.ForProvider.PostgresName == nil {
+		return nil, errors.New(errNoPostgres)
+	}
+	ps, err := postgres.GetInstance(ctx, e.kube, *cr.Spec.ForProvider.PostgresName)
+	return ps, errors.Wrap(err, errGetPostgresMsg)
+}
+
+// observe reads the role with the given name from the instance. It returns
+// nil if the role does not exist.
+func (e *external) observe(ctx context.Context, ps *v1alpha1.Postgres, name string) (*observedRole, error) {
+	out, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, fmt.Sprintf(observeRoleQuery, postgres.QuoteLiteral(name)))
+	if err != nil {
+		return nil, errors.Wrap(err, errObserveMsg)
+	}
+	rows := postgres.SplitRows(out)
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+	if len(row) != 8 {
+		return nil, errors.Errorf(errUnexpectedRow, strings.Join(row, "|"))
+	}
+	connLimit, err := strconv.Atoi(row[4])
+	if err != nil {
+		return nil, errors.Errorf(errUnexpectedRow, strings.Join(row, "|"))
+	}
+	o := &observedRole{
+		login:      row[0] == "t",
+		createDB:   row[1] == "t",
+		createRole: row[2] == "t",
+		inherit:    row[3] == "t",
+		connLimit:  connLimit,
+		password:   row[5],
+		memberOf:   splitList(row[6]),
+		grants:     map[string][]string{},
+	}
+	for _, g := range splitList(row[7]) {
+		i := strings.LastIndex(g, ":")
+		if i < 0 {
+			return nil, errors.Errorf(errUnexpectedRow, strings.Join(row, "|"))
+		}
+		o.grants[g[:i]] = append(o.grants[g[:i]], g[i+1:])
+	}
+	return o, nil
+}
+
+// password returns the password of the role given in the password secret, or
+// an empty string if the password is generated
+func (e *external) password(ctx context.Context, cr *v1alpha1.PostgresRole) (string, error) {
+	ref := cr.Spec.ForProvider.PasswordSecretRef
+	if ref == nil {
+		return "", nil
+	}
+	s := &v1.Secret{}
+	if err := e.kube.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, s); err != nil {
+		return "", errors.Wrap(err, errPasswordSecretMsg)
+	}
+	return string(s.Data[ref.Key]), nil
+}
+
+// isUpToDate compares the observed role against the spec. A generated
+// password is never compared, it is only known at creation time.
+func isUpToDate(cr *v1alpha1.PostgresRole, o *observedRole, password string) bool {
+	p := cr.Spec.ForProvider
+	if utils.BoolValueFallback(p.Login, true) != o.login ||
+		utils.BoolValueFallback(p.CreateDB, false) != o.createDB ||
+		utils.BoolValueFallback(p.CreateRole, false) != o.createRole ||
+		utils.BoolValueFallback(p.Inherit, true) != o.inherit ||
+		connectionLimit(p.ConnectionLimit) != o.connLimit {
+		return false
+	}
+	if password != "" && !postgres.PasswordMatches(o.password, meta.GetExternalName(cr), password) {
+		return false
+	}
+	add, remove := diff(p.MemberOf, o.memberOf)
+	if len(add) > 0 || len(remove) > 0 {
+		return false
+	}
+	desired := desiredGrants(p.Grants)
+	for db := range union(desired, o.grants) {
+		add, remove := diff(desired[db], o.grants[db])
+		if len(add) > 0 || len(remove) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresRole)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	password, err := e.password(ctx, cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if password == "" {
+		if password, err = e.client.GeneratePassword(); err != nil {
+			return managed.ExternalCreation{}, errors.Wrap(err, errGeneratePasswordMsg)
+		}
+	}
+
+	name := meta.GetExternalName(cr)
+	statements := []string{fmt.Sprintf("CREATE ROLE %s WITH %s PASSWORD %s",
+		postgres.QuoteIdentifier(name), attributes(cr), postgres.QuoteLiteral(password))}
+	statements = append(statements, membershipStatements(name, cr.Spec.ForProvider.MemberOf, nil)...)
+	statements = append(statements, grantStatements(name, desiredGrants(cr.Spec.ForProvider.Grants), nil)...)
+	// a role created without its grants would keep a generated password which
+	// is never published
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, postgres.Transaction(statements...)...); err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreateMsg)
+	}
+	return managed.ExternalCreation{ConnectionDetails: connectionDetails(cr, ps, password)}, nil
+}
+
+func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresRole)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	name := meta.GetExternalName(cr)
+	observed, err := e.observe(ctx, ps, name)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	if observed == nil {
+		return managed.ExternalUpdate{}, errors.Errorf(errRoleNotFound, name)
+	}
+	password, err := e.password(ctx, cr)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+
+	statements := []string{fmt.Sprintf("ALTER ROLE %s WITH %s", postgres.QuoteIdentifier(name), attributes(cr))}
+	if password != "" && !postgres.PasswordMatches(observed.password, name, password) {
+		statements = append(statements, fmt.Sprintf("ALTER ROLE %s WITH PASSWORD %s",
+			postgres.QuoteIdentifier(name), postgres.QuoteLiteral(password)))
+	}
+	statements = append(statements, membershipStatements(name, cr.Spec.ForProvider.MemberOf, observed.memberOf)...)
+	statements = append(statements, grantStatements(name, desiredGrants(cr.Spec.ForProvider.Grants), observed.grants)...)
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, postgres.Transaction(statements...)...); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateMsg)
+	}
+	return managed.ExternalUpdate{ConnectionDetails: connectionDetails(cr, ps, password)}, nil
+}
+
+func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
+	cr, ok := mgd.(*v1alpha1.PostgresRole)
+	if !ok {
+		return errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return err
+	}
+	name := meta.GetExternalName(cr)
+	observed, err := e.observe(ctx, ps, name)
+	if err != nil || observed == nil {
+		return err
+	}
+	// privileges on databases are dependencies which prevent dropping the role
+	statements := grantStatements(name, nil, observed.grants)
+	statements = append(statements, fmt.Sprintf("DROP ROLE IF EXISTS %s", postgres.QuoteIdentifier(name)))
+	_, err = e.client.ExecSQL(ctx, ps, maintenanceDatabase, statements...)
+	return errors.Wrap(err, errDeleteMsg)
+}
+
+// connectionDetails returns the credentials of the role. The password is
+// omitted if it is not known.
+func connectionDetails(cr *v1alpha1.PostgresRole, ps *v1alpha1.Postgres, password string) managed.ConnectionDetails {
+	details := managed.ConnectionDetails{
+		runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(meta.GetExternalName(cr)),
+		runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(postgres.PrimaryHost(ps)),
+		runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte(strconv.Itoa(postgres.Port(ps))),
+	}
+	if password != "" {
+		details[runtimev1alpha1.ResourceCredentialsSecretPasswordKey] = []byte(password)
+	}
+	return details
+}
+
+// attributes renders the role attributes of the spec
+func attributes(cr *v1alpha1.PostgresRole) string {
+	p := cr.Spec.ForProvider
+	return strings.Join([]string{
+		attribute("LOGIN", utils.BoolValueFallback(p.Login, true)),
+		attribute("CREATEDB", utils.BoolValueFallback(p.CreateDB, false)),
+		attribute("CREATEROLE", utils.BoolValueFallback(p.CreateRole, false)),
+		attribute("INHERIT", utils.BoolValueFallback(p.Inherit, true)),
+		"CONNECTION LIMIT " + strconv.Itoa(connectionLimit(p.ConnectionLimit)),
+	}, " ")
+}
+
+func attribute(name string, set bool) string {
+	if set {
+		return name
+	}
+	return "NO" + name
+}
+
+func connectionLimit(limit *int) int {
+	if limit == nil {
+		return -1
+	}
+	return *limit
+}
+
+// membershipStatements grants and revokes memberships so that the role is a
+// member of exactly the desired roles
+func membershipStatements(name string, desired, observed []string) []string {
+	var statements []string
+	add, remove := diff(desired, observed)
+	for _, r := range add {
+		statements = append(statements, fmt.Sprintf("GRANT %s TO %s", postgres.QuoteIdentifier(r), postgres.QuoteIdentifier(name)))
+	}
+	for _, r := range remove {
+		statements = append(statements, fmt.Sprintf("REVOKE %s FROM %s", postgres.QuoteIdentifier(r), postgres.QuoteIdentifier(name)))
+	}
+	return statements
+}
+
+// grantStatements grants and revokes privileges so that the role holds
+// exactly the desired privileges on every database
+func grantStatements(name string, desired, observed map[string][]string) []string {
+	var statements []string
+	dbs := make([]string, 0)
+	for db := range union(desired, observed) {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+	for _, db := range dbs {
+		add, remove := diff(desired[db], observed[db])
+		if len(add) > 0 {
+			statements = append(statements, fmt.Sprintf("GRANT %s ON DATABASE %s TO %s",
+				strings.Join(add, ", "), postgres.QuoteIdentifier(db), postgres.QuoteIdentifier(name)))
+		}
+		if len(remove) > 0 {
+			statements = append(statements, fmt.Sprintf("REVOKE %s ON DATABASE %s FROM %s",
+				strings.Join(remove, ", "), postgres.QuoteIdentifier(db), postgres.QuoteIdentifier(name)))
+		}
+	}
+	return statements
+}
+
+// desiredGrants returns the privileges granted by the spec per database, with
+// ALL expanded to the individual privileges
+func desiredGrants(grants []v1alpha1.PostgresGrant) map[string][]string {
+	desired := map[string][]string{}
+	for _, g := range grants {
+		for _, p := range g.Privileges {
+			if p == v1alpha1.PrivilegeAll {
+				desired[g.Database] = append(desired[g.Database],
+					string(v1alpha1.PrivilegeConnect), string(v1alpha1.PrivilegeCreate), string(v1alpha1.PrivilegeTemporary))
+				continue
+			}
+			desired[g.Database] = append(desired[g.Database], string(p))
+		}
+	}
+	return desired
+}
+
+// diff returns the sorted elements only found in desired and the sorted
+// elements only found in observed
+func diff(desired, observed []string) (add, remove []string) {
+	want, got := map[string]bool{}, map[string]bool{}
+	for _, d := range desired {
+		want[d] = true
+	}
+	for _, o := range observed {
+		got[o] = true
+	}
+	for d := range want {
+		if !got[d] {
+			add = append(add, d)
+		}
+	}
+	for o := range got {
+		if !want[o] {
+			remove = append(remove, o)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
+
+func union(a, b map[string][]string) map[string]bool {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,598 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresrole
+
+import (
+	"context"
+	"testing"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres/fake"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+var (
+	// an arbitrary managed resource
+	unexpectedItem resource.Managed
+	errBoom        = errors.New("boom")
+
+	roleName      = "app"
+	postgresName  = "postgresdb"
+	endpoint      = "postgresdb.default.svc"
+	userPass      = "password"
+	generatedPass = "123asdf"
+	// scramPass is the SCRAM-SHA-256 verifier of userPass
+	scramPass = "SCRAM-SHA-256$4096:MDEyMzQ1Njc4OWFiY2RlZg==$wjGCKoCIcEWiPxSG7t/wnb/YICMEFr1JZNZSKNje12g=:6NG/vkzOjK2oyl11qeNEBeKuOY3QQ4atswXYbIBO79Q="
+	// md5Pass is the md5 hash of userPass for roleName
+	md5Pass = "md598b0eddb1d41e30a28e098217145c424"
+)
+
+type args struct {
+	pg   postgres.Client
+	kube client.Client
+	cr   resource.Managed
+}
+
+// RoleModifier is a function which modifies the PostgresRole for testing
+type RoleModifier func(cr *v1alpha1.PostgresRole)
+
+func withPasswordSecret() RoleModifier {
+	return func(cr *v1alpha1.PostgresRole) {
+		cr.Spec.ForProvider.PasswordSecretRef = &runtimev1alpha1.SecretKeySelector{
+			SecretReference: runtimev1alpha1.SecretReference{Name: "secret", Namespace: "default"},
+			Key:             "password",
+		}
+	}
+}
+
+func withGrants(grants ...v1alpha1.PostgresGrant) RoleModifier {
+	return func(cr *v1alpha1.PostgresRole) {
+		cr.Spec.ForProvider.Grants = grants
+	}
+}
+
+func withMemberOf(roles ...string) RoleModifier {
+	return func(cr *v1alpha1.PostgresRole) {
+		cr.Spec.ForProvider.MemberOf = roles
+	}
+}
+
+func withCreateDB() RoleModifier {
+	return func(cr *v1alpha1.PostgresRole) {
+		cr.Spec.ForProvider.CreateDB = utils.Bool(true)
+	}
+}
+
+func withConditions(conditions ...runtimev1alpha1.Condition) RoleModifier {
+	return func(cr *v1alpha1.PostgresRole) {
+		cr.Status.Conditions = conditions
+	}
+}
+
+// Role creates a v1alpha1 PostgresRole for use in testing
+func Role(m ...RoleModifier) *v1alpha1.PostgresRole {
+	cr := &v1alpha1.PostgresRole{
+		Spec: v1alpha1.PostgresRoleSpec{
+			ForProvider: v1alpha1.PostgresRoleParameters{
+				PostgresName: utils.String(postgresName),
+			},
+		},
+	}
+	for _, f := range m {
+		f(cr)
+	}
+	meta.SetExternalName(cr, roleName)
+	return cr
+}
+
+// mockGet returns the instance and the password secret
+func mockGet(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	switch o := obj.(type) {
+	case *v1alpha1.Postgres:
+		o.Name = key.Name
+	case *v1.Secret:
+		o.Data = map[string][]byte{"password": []byte(userPass)}
+	}
+	return nil
+}
+
+// mockExecSQL returns the given output for every query and records the
+// executed statements
+func mockExecSQL(out string, executed *[]string) func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+	return func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+		if executed != nil {
+			*executed = append(*executed, statements...)
+		}
+		return out, nil
+	}
+}
+
+func TestObserve(t *testing.T) {
+
+	type want struct {
+		cr     resource.Managed
+		result managed.ExternalObservation
+		err    error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				cr:  unexpectedItem,
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"GetPostgresError": {
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(errBoom),
+				},
+				cr: Role(),
+			},
+			want: want{
+				cr:  Role(),
+				err: errors.Wrap(errBoom, errGetPostgresMsg),
+			},
+		},
+		"ExecError": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+						return "", errBoom
+					},
+				},
+				cr: Role(),
+			},
+			want: want{
+				cr:  Role(),
+				err: errors.Wrap(errBoom, errObserveMsg),
+			},
+		},
+		"NotFound": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{MockExecSQL: mockExecSQL("", nil)},
+				cr:   Role(),
+			},
+			want: want{
+				cr:     Role(),
+				result: managed.ExternalObservation{},
+			},
+		},
+		"UpToDate": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: mockExecSQL("t|t|f|t|-1|"+scramPass+"|readers|app:CONNECT,app:TEMPORARY\n", nil),
+				},
+				cr: Role(withPasswordSecret(), withCreateDB(), withMemberOf("readers"), withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeConnect, v1alpha1.PrivilegeTemporary},
+				})),
+			},
+			want: want{
+				cr: Role(withPasswordSecret(), withCreateDB(), withMemberOf("readers"), withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeConnect, v1alpha1.PrivilegeTemporary},
+				}), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: managed.ConnectionDetails{
+					runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(roleName),
+					runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(userPass),
+					runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+					runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+				}},
+			},
+		},
+		"PasswordChanged": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: mockExecSQL("t|f|f|t|-1|md5ffffffffffffffffffffffffffffffff||\n", nil),
+				},
+				cr: Role(withPasswordSecret()),
+			},
+			want: want{
+				cr: Role(withPasswordSecret(), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false, ConnectionDetails: managed.ConnectionDetails{
+					runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(roleName),
+					runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(userPass),
+					runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+					runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+				}},
+			},
+		},
+		"GrantDrift": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: mockExecSQL("t|f|f|t|-1|"+md5Pass+"||app:CONNECT,app:CREATE\n", nil),
+				},
+				cr: Role(withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeConnect},
+				})),
+			},
+			want: want{
+				cr: Role(withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeConnect},
+				}), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false, ConnectionDetails: managed.ConnectionDetails{
+					runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(roleName),
+					runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+					runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+				}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			o, err := e.Observe(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cr, tc.args.cr, test.EquateConditions()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	type want struct {
+		cr         resource.Managed
+		result     managed.ExternalCreation
+		statements []string
+		err        error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				cr:  unexpectedItem,
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"GeneratePasswordError": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockGeneratePassword: func() (string, error) {
+						return "", errBoom
+					},
+				},
+				cr: Role(),
+			},
+			want: want{
+				cr:  Role(),
+				err: errors.Wrap(errBoom, errGeneratePasswordMsg),
+			},
+		},
+		"ExecError": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockGeneratePassword: func() (string, error) {
+						return generatedPass, nil
+					},
+					MockExecSQL: func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+						return "", errBoom
+					},
+				},
+				cr: Role(),
+			},
+			want: want{
+				cr:  Role(),
+				err: errors.Wrap(errBoom, errCreateMsg),
+			},
+		},
+		"GeneratedPassword": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg: &fake.MockPostgresClient{
+					MockGeneratePassword: func() (string, error) {
+						return generatedPass, nil
+					},
+				},
+				cr: Role(withMemberOf("readers"), withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeAll},
+				})),
+			},
+			want: want{
+				cr: Role(withMemberOf("readers"), withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeAll},
+				})),
+				result: managed.ExternalCreation{ConnectionDetails: managed.ConnectionDetails{
+					runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(roleName),
+					runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(generatedPass),
+					runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+					runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+				}},
+				statements: []string{
+					`BEGIN`,
+					`CREATE ROLE "app" WITH LOGIN NOCREATEDB NOCREATEROLE INHERIT CONNECTION LIMIT -1 PASSWORD '123asdf'`,
+					`GRANT "readers" TO "app"`,
+					`GRANT CONNECT, CREATE, TEMPORARY ON DATABASE "app" TO "app"`,
+					`COMMIT`,
+				},
+			},
+		},
+		"SecretPassword": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Role(withPasswordSecret(), withCreateDB()),
+			},
+			want: want{
+				cr: Role(withPasswordSecret(), withCreateDB()),
+				result: managed.ExternalCreation{ConnectionDetails: managed.ConnectionDetails{
+					runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(roleName),
+					runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(userPass),
+					runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+					runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+				}},
+				statements: []string{
+					`BEGIN`,
+					`CREATE ROLE "app" WITH LOGIN CREATEDB NOCREATEROLE INHERIT CONNECTION LIMIT -1 PASSWORD 'password'`,
+					`COMMIT`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok && pg.MockExecSQL == nil {
+				pg.MockExecSQL = mockExecSQL("", &executed)
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			o, err := e.Create(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cr, tc.args.cr, test.EquateConditions()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.statements, executed); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	type want struct {
+		result     managed.ExternalUpdate
+		statements []string
+		err        error
+	}
+
+	cases := map[string]struct {
+		args
+		observed string
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"NotFound": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Role(),
+			},
+			want: want{
+				err:        errors.Errorf(errRoleNotFound, roleName),
+				statements: []string{`<observe>`},
+			},
+		},
+		"ConvergeRole": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr: Role(withPasswordSecret(), withMemberOf("writers"), withGrants(v1alpha1.PostgresGrant{
+					Database:   "app",
+					Privileges: []v1alpha1.PostgresDatabasePrivilege{v1alpha1.PrivilegeConnect},
+				})),
+			},
+			observed: "t|t|f|t|-1|md5ffffffffffffffffffffffffffffffff|readers|app:CREATE,other:CONNECT\n",
+			want: want{
+				result: managed.ExternalUpdate{ConnectionDetails: managed.ConnectionDetails{
+					runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(roleName),
+					runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(userPass),
+					runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(endpoint),
+					runtimev1alpha1.ResourceCredentialsSecretPortKey:     []byte("5432"),
+				}},
+				statements: []string{
+					`<observe>`,
+					`BEGIN`,
+					`ALTER ROLE "app" WITH LOGIN NOCREATEDB NOCREATEROLE INHERIT CONNECTION LIMIT -1`,
+					`ALTER ROLE "app" WITH PASSWORD 'password'`,
+					`GRANT "writers" TO "app"`,
+					`REVOKE "readers" FROM "app"`,
+					`GRANT CONNECT ON DATABASE "app" TO "app"`,
+					`REVOKE CREATE ON DATABASE "app" FROM "app"`,
+					`REVOKE CONNECT ON DATABASE "other" FROM "app"`,
+					`COMMIT`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok {
+				pg.MockExecSQL = func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+					if len(executed) == 0 {
+						executed = append(executed, "<observe>")
+						return tc.observed, nil
+					}
+					executed = append(executed, statements...)
+					return "", nil
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			o, err := e.Update(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.statements, executed); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	type want struct {
+		statements []string
+		err        error
+	}
+
+	cases := map[string]struct {
+		args
+		observed string
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"AlreadyGone": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Role(),
+			},
+			want: want{
+				statements: []string{`<observe>`},
+			},
+		},
+		"RevokeAndDrop": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGet},
+				pg:   &fake.MockPostgresClient{},
+				cr:   Role(),
+			},
+			observed: "t|f|f|t|-1|||app:CONNECT\n",
+			want: want{
+				statements: []string{
+					`<observe>`,
+					`REVOKE CONNECT ON DATABASE "app" FROM "app"`,
+					`DROP ROLE IF EXISTS "app"`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var executed []string
+			if pg, ok := tc.pg.(*fake.MockPostgresClient); ok {
+				pg.MockExecSQL = func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+					if len(executed) == 0 {
+						executed = append(executed, "<observe>")
+						return tc.observed, nil
+					}
+					executed = append(executed, statements...)
+					return "", nil
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				logger: logging.NewNopLogger(),
+			}
+			err := e.Delete(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.statements, executed); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgressnapshot
+
+import (
+	"context"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+)
+
+const (
+	errUnexpectedObject  = "the managed resource is not a PostgresSnapshot resource" //nolint:golint
+	errNoPostgres        = "the postgres instance of the snapshot is not set"
+	errGetPostgresMsg    = "failed to get postgres instance"                       //nolint:golint
+	errSnapshotMsg       = "failed to get postgres volume snapshot"                //nolint:golint
+	errSnapshotCreateMsg = "failed to create postgres volume snapshot"             //nolint:golint
+	errCheckpointMsg     = "failed to run a checkpoint before taking the snapshot" //nolint:golint
+	errUpdateMsg         = "failed to update postgres volume snapshot"             //nolint:golint
+	errDeleteMsg         = "failed to delete postgres volume snapshot"             //nolint:golint
+
+	maintenanceDatabase = "postgres"
+)
+
+// SetupPostgresSnapshot adds a controller that reconciles PostgresSnapshots.
+func SetupPostgresSnapshot(mgr ctrl.Manager, l logging.Logger) error {
+	name := managed.ControllerName(v1alpha1.PostgresSnapshotGroupKind)
+	logger := l.WithValues("controller", name)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1alpha1.PostgresSnapshot{}).
+		Complete(managed.NewReconciler(mgr,
+			resource.ManagedKind(v1alpha1.PostgresSnapshotGroupVersionKind),
+			managed.WithExternalConnecter(&connector{kube: mgr.GetClient(), newClientFn: postgres.NewRoleClient, logger: logger}),
+			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLogger(logger),
+			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+type connector struct {
+	kube        client.Client
+	newClientFn func(kube client.Client, cs kubernetes.Interface, rc *rest.Config) postgres.Client
+	logger      logging.Logger
+}
+
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.PostgresSnapshot)
+	if !ok {
+		return nil, errors.New(errUnexpectedObject)
+	}
+
+	c.logger.Debug("Connecting")
+
+	rc, err := clients.GetProviderConfigRC(ctx, cr, c.kube)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	kube, err := client.New(rc, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &external{client: c.newClientFn(kube, cs, rc), kube: c.kube, target: kube, logger: c.logger}, nil
+}
+
+// external manages the VolumeSnapshot of an instance. kube is the client of
+// the cluster the managed resources live in, target the client of the cluster
+// the instance runs in.
+type external struct {
+	client postgres.Client
+	kube   client.Client
+	target client.Client
+	logger logging.Logger
+}
+
+func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresSnapshot)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errUnexpectedObject)
+	}
+	namespace, err := e.namespace(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	snap := &unstructured.Unstructured{}
+	snap.SetGroupVersionKind(postgres.VolumeSnapshotGroupVersionKind)
+	err = e.target.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: namespace}, snap)
+	if kerrors.IsNotFound(err) {
+		return managed.ExternalObservation{}, nil
+	}
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(err, errSnapshotMsg)
+	}
+	owned, err := clients.ObserveOwner(snap, owner(cr))
+	if err != nil {
+		return managed.ExternalObservation{ResourceExists: true}, err
+	}
+
+	postgres.SetSnapshotStatus(cr, snap)
+	ready, err := postgres.IsSnapshotReady(snap)
+	switch {
+	case err != nil:
+		// a failed snapshot is not retried but can still be deleted
+		cr.SetConditions(runtimev1alpha1.Unavailable().WithMessage(err.Error()))
+	case ready:
+		cr.SetConditions(runtimev1alpha1.Available())
+	default:
+		cr.SetConditions(runtimev1alpha1.Creating())
+	}
+
+	// the spec of a snapshot cannot be changed, only an adopted snapshot is
+	// labelled with its owner
+	return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: owned}, nil
+}
+
+// owner returns the owner labels and annotations of the VolumeSnapshot of the
+// given PostgresSnapshot
+func owner(cr *v1alpha1.PostgresSnapshot) metav1.Object {
+	om := &metav1.ObjectMeta{}
+	clients.SetOwner(om, cr, v1alpha1.PostgresSnapshotGroupVersionKind)
+	return om
+}
+
+// namespace returns the namespace of the snapshot. It is recorded once the
+// snapshot is observed, so that the snapshot outlives its instance.
+func (e *external) namespace(ctx context.Context, cr *v1alpha1.PostgresSnapshot) (string, error) {
+	if cr.Status.AtProvider.Namespace != "" {
+		return cr.Status.AtProvider.Namespace, nil
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return "", err
+	}
+	return ps.Namespace, nil
+}
+
+// instance fetches the Postgres instance which is snapshotted
+func (e *external) instance(ctx context.Context, cr *v1alpha1.PostgresSnapshot) (*v1alpha1.Postgres, error) {
+	if cr.Spec.ForProvider.PostgresName == nil {
+		return nil, errors.New(errNoPostgres)
+	}
+	ps, err := postgres.GetInstance(ctx, e.kube, *cr.Spec.ForProvider.PostgresName)
+	return ps, errors.Wrap(err, errGetPostgresMsg)
+}
+
+// Create runs a checkpoint in the instance and takes the snapshot of its PVC
+// right after, so that an instance restored from the crash consistent
+// snapshot has little WAL to replay. The WAL lives on the same PVC as the
+// data, so no pg_backup_start is needed, which would have to hold a session
+// open until the snapshot is cut.
+func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresSnapshot)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
+	}
+	ps, err := e.instance(ctx, cr)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	claim, err := postgres.SnapshotClaimName(ps)
+	if err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if _, err := e.client.ExecSQL(ctx, ps, maintenanceDatabase, postgres.CheckpointQuery); err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCheckpointMsg)
+	}
+	cr.SetConditions(runtimev1alpha1.Creating())
+	return managed.ExternalCreation{}, errors.Wrap(e.target.Create(ctx, postgres.MakeSnapshot(cr, ps, claim)), errSnapshotCreateMsg)
+}
+
+// Update labels an adopted VolumeSnapshot with its owner, the spec of a
+// snapshot cannot be changed
+func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mgd.(*v1alpha1.PostgresSnapshot)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
+	}
+	namespace, err := e.namespace(ctx, cr)
+	if err != nil {
+		return managed.ExternalUpdate{}, err
+	}
+	snap := &unstructured.Unstructured{}
+	snap.SetGroupVersionKind(postgres.VolumeSnapshotGroupVersionKind)
+	if err := e.target.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: namespace}, snap); err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errSnapshotMsg)
+	}
+	clients.CopyOwner(owner(cr), snap)
+	return managed.ExternalUpdate{}, errors.Wrap(e.target.Update(ctx, snap), errUpdateMsg)
+}
+
+// Delete removes the VolumeSnapshot if the PostgresSnapshot owns it
+func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
+	cr, ok := mgd.(*v1alpha1.PostgresSnapshot)
+	if !ok {
+		return errors.New(errUnexpectedObject)
+	}
+	namespace, err := e.namespace(ctx, cr)
+	if err != nil {
+		return err
+	}
+	snap := &unstructured.Unstructured{}
+	snap.SetGroupVersionKind(postgres.VolumeSnapshotGroupVersionKind)
+	err = e.target.Get(ctx, types.NamespacedName{Name: cr.Name, Namespace: namespace}, snap)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errSnapshotMsg)
+	}
+	if !clients.IsOwnedBy(snap, cr) {
+		return nil
+	}
+	return errors.Wrap(client.IgnoreNotFound(e.target.Delete(ctx, snap)), errDeleteMsg)
+}
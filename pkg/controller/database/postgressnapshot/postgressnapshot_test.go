@@ -0,0 +1,534 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgressnapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane-contrib/provider-in-cluster/apis/database/v1alpha1"
+	clients "github.com/crossplane-contrib/provider-in-cluster/pkg/client"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/client/database/postgres/fake"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/utils"
+)
+
+var (
+	// an arbitrary managed resource
+	unexpectedItem resource.Managed
+	errBoom        = errors.New("boom")
+
+	snapshotName = "before-migration"
+	snapshotUID  = types.UID("d7f3c6a0-1b2e-4c55-9a4e-0c6f3a1d2b10")
+	postgresName = "postgresdb"
+	restoreSize  = "10Gi"
+	taken        = metav1.NewTime(time.Date(2020, 10, 16, 3, 0, 5, 0, time.UTC))
+)
+
+type args struct {
+	pg     postgres.Client
+	kube   client.Client
+	target client.Client
+	cr     resource.Managed
+}
+
+// SnapshotModifier is a function which modifies the PostgresSnapshot for
+// testing
+type SnapshotModifier func(cr *v1alpha1.PostgresSnapshot)
+
+func withTaken(ready bool) SnapshotModifier {
+	return func(cr *v1alpha1.PostgresSnapshot) {
+		cr.Status.AtProvider = v1alpha1.PostgresSnapshotObservation{
+			Namespace: postgres.DefaultNamespace,
+			SourcePVC: postgresName,
+		}
+		if ready {
+			cr.Status.AtProvider.ReadyToUse = true
+			cr.Status.AtProvider.RestoreSize = restoreSize
+			cr.Status.AtProvider.CreationTime = &taken
+		}
+	}
+}
+
+func withConditions(conditions ...runtimev1alpha1.Condition) SnapshotModifier {
+	return func(cr *v1alpha1.PostgresSnapshot) {
+		cr.Status.Conditions = conditions
+	}
+}
+
+// Snapshot creates a v1alpha1 PostgresSnapshot for use in testing
+func Snapshot(m ...SnapshotModifier) *v1alpha1.PostgresSnapshot {
+	cr := &v1alpha1.PostgresSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: snapshotName, UID: snapshotUID},
+		Spec: v1alpha1.PostgresSnapshotSpec{
+			ForProvider: v1alpha1.PostgresSnapshotParameters{
+				PostgresName: utils.String(postgresName),
+			},
+		},
+	}
+	for _, f := range m {
+		f(cr)
+	}
+	return cr
+}
+
+func instance() *v1alpha1.Postgres {
+	return &v1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: postgresName, Namespace: postgres.DefaultNamespace}}
+}
+
+// mockGetInstance returns the Postgres instance from the control plane
+func mockGetInstance(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if o, ok := obj.(*v1alpha1.Postgres); ok {
+		o.Name = key.Name
+	}
+	return nil
+}
+
+// mockGetTarget returns the VolumeSnapshot from the cluster the instance runs
+// in, if snap is not nil
+func mockGetTarget(snap *unstructured.Unstructured) test.MockGetFn {
+	return func(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+		if o, ok := obj.(*unstructured.Unstructured); ok {
+			if snap == nil {
+				return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+			}
+			snap.DeepCopyInto(o)
+		}
+		return nil
+	}
+}
+
+// volumeSnapshot returns the VolumeSnapshot of the PostgresSnapshot, ready to
+// use if ready is set
+func volumeSnapshot(ready bool) *unstructured.Unstructured {
+	u := postgres.MakeSnapshot(Snapshot(), instance(), postgresName)
+	u.SetResourceVersion("1")
+	if ready {
+		u.Object["status"] = map[string]interface{}{
+			"readyToUse":   true,
+			"restoreSize":  restoreSize,
+			"creationTime": taken.UTC().Format(time.RFC3339),
+		}
+	}
+	return u
+}
+
+// foreignSnapshot returns the VolumeSnapshot of the PostgresSnapshot as
+// another managed resource created it
+func foreignSnapshot(adopt bool) *unstructured.Unstructured {
+	u := volumeSnapshot(true)
+	labels := u.GetLabels()
+	labels[clients.LabelOwnerUID] = "other"
+	u.SetLabels(labels)
+	if adopt {
+		u.SetAnnotations(map[string]string{clients.AnnotationAdopt: "true"})
+	}
+	return u
+}
+
+func TestObserve(t *testing.T) {
+
+	type want struct {
+		cr     resource.Managed
+		result managed.ExternalObservation
+		err    error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				cr:  unexpectedItem,
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"NoPostgres": {
+			args: args{
+				cr: &v1alpha1.PostgresSnapshot{},
+			},
+			want: want{
+				cr:  &v1alpha1.PostgresSnapshot{},
+				err: errors.New(errNoPostgres),
+			},
+		},
+		"GetSnapshotError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:     Snapshot(),
+			},
+			want: want{
+				cr:  Snapshot(),
+				err: errors.Wrap(errBoom, errSnapshotMsg),
+			},
+		},
+		"NotFound": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(nil)},
+				cr:     Snapshot(),
+			},
+			want: want{
+				cr:     Snapshot(),
+				result: managed.ExternalObservation{},
+			},
+		},
+		"Pending": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(volumeSnapshot(false))},
+				cr:     Snapshot(),
+			},
+			want: want{
+				cr:     Snapshot(withTaken(false), withConditions(runtimev1alpha1.Creating())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+			},
+		},
+		"Ready": {
+			args: args{
+				target: &test.MockClient{MockGet: mockGetTarget(volumeSnapshot(true))},
+				cr:     Snapshot(withTaken(false)),
+			},
+			want: want{
+				cr:     Snapshot(withTaken(true), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+			},
+		},
+		"Failed": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: func(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+					u := volumeSnapshot(false)
+					u.Object["status"] = map[string]interface{}{"error": map[string]interface{}{"message": "boom"}}
+					u.DeepCopyInto(obj.(*unstructured.Unstructured))
+					return nil
+				}},
+				cr: Snapshot(),
+			},
+			want: want{
+				cr: Snapshot(withTaken(false), withConditions(runtimev1alpha1.Unavailable().WithMessage(
+					errors.Errorf("snapshot %s failed: %s", snapshotName, "boom").Error()))),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+			},
+		},
+		"NotOwned": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(foreignSnapshot(false))},
+				cr:     Snapshot(),
+			},
+			want: want{
+				cr:     Snapshot(),
+				result: managed.ExternalObservation{ResourceExists: true},
+				err:    clients.CheckAdoption(foreignSnapshot(false), volumeSnapshot(false)),
+			},
+		},
+		"Adopt": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(foreignSnapshot(true))},
+				cr:     Snapshot(),
+			},
+			want: want{
+				cr:     Snapshot(withTaken(true), withConditions(runtimev1alpha1.Available())),
+				result: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			o, err := e.Observe(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.cr, tc.args.cr, test.EquateConditions()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, o); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	type want struct {
+		snapshot *unstructured.Unstructured
+		err      error
+	}
+
+	checkpoint := &fake.MockPostgresClient{
+		MockExecSQL: func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+			if diff := cmp.Diff([]string{postgres.CheckpointQuery}, statements); diff != "" {
+				return "", errors.New(diff)
+			}
+			return "", nil
+		},
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"NoInstance": {
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, postgresName))},
+				cr:   Snapshot(),
+			},
+			want: want{
+				err: errors.Wrap(kerrors.NewNotFound(schema.GroupResource{}, postgresName), errGetPostgresMsg),
+			},
+		},
+		"CheckpointError": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGetInstance},
+				pg: &fake.MockPostgresClient{
+					MockExecSQL: func(ctx context.Context, ps *v1alpha1.Postgres, database string, statements ...string) (string, error) {
+						return "", errBoom
+					},
+				},
+				cr: Snapshot(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errCheckpointMsg),
+			},
+		},
+		"CreateError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+				pg:     checkpoint,
+				cr:     Snapshot(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errSnapshotCreateMsg),
+			},
+		},
+		"Successful": {
+			args: args{
+				kube: &test.MockClient{MockGet: mockGetInstance},
+				pg:   checkpoint,
+				cr:   Snapshot(),
+			},
+			want: want{
+				snapshot: postgres.MakeSnapshot(Snapshot(), instance(), postgresName),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var created *unstructured.Unstructured
+			if tc.target == nil {
+				tc.target = &test.MockClient{MockCreate: func(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+					created = obj.(*unstructured.Unstructured)
+					return nil
+				}}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			_, err := e.Create(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.snapshot, created); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	type want struct {
+		labels map[string]string
+		err    error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"UpdateError": {
+			args: args{
+				target: &test.MockClient{MockGet: mockGetTarget(foreignSnapshot(true)), MockUpdate: test.NewMockUpdateFn(errBoom)},
+				cr:     Snapshot(withTaken(true)),
+			},
+			want: want{
+				labels: volumeSnapshot(true).GetLabels(),
+				err:    errors.Wrap(errBoom, errUpdateMsg),
+			},
+		},
+		"Adopted": {
+			args: args{
+				target: &test.MockClient{MockGet: mockGetTarget(foreignSnapshot(true)), MockUpdate: test.NewMockUpdateFn(nil)},
+				cr:     Snapshot(withTaken(true)),
+			},
+			want: want{
+				labels: volumeSnapshot(true).GetLabels(),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var updated map[string]string
+			if m, ok := tc.target.(*test.MockClient); ok {
+				fn := m.MockUpdate
+				m.MockUpdate = func(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+					updated = obj.(*unstructured.Unstructured).GetLabels()
+					return fn(ctx, obj, opts...)
+				}
+			}
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			_, err := e.Update(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.labels, updated); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	type want struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InValidInput": {
+			args: args{
+				cr: unexpectedItem,
+			},
+			want: want{
+				err: errors.New(errUnexpectedObject),
+			},
+		},
+		"AlreadyGone": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(nil)},
+				cr:     Snapshot(),
+			},
+			want: want{},
+		},
+		"InstanceGone": {
+			args: args{
+				target: &test.MockClient{MockGet: mockGetTarget(volumeSnapshot(true)), MockDelete: test.NewMockDeleteFn(nil)},
+				cr:     Snapshot(withTaken(true)),
+			},
+			want: want{},
+		},
+		"DeleteError": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(volumeSnapshot(true)), MockDelete: test.NewMockDeleteFn(errBoom)},
+				cr:     Snapshot(),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errDeleteMsg),
+			},
+		},
+		"NotOwned": {
+			args: args{
+				kube:   &test.MockClient{MockGet: mockGetInstance},
+				target: &test.MockClient{MockGet: mockGetTarget(foreignSnapshot(false)), MockDelete: test.NewMockDeleteFn(errBoom)},
+				cr:     Snapshot(),
+			},
+			want: want{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := &external{
+				client: tc.pg,
+				kube:   tc.kube,
+				target: tc.target,
+				logger: logging.NewNopLogger(),
+			}
+			err := e.Delete(context.Background(), tc.args.cr)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
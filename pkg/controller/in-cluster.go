@@ -22,6 +22,11 @@ import (
 
 	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/config"
 	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/database/postgres"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/database/postgresbackup"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/database/postgresdatabase"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/database/postgrespooler"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/database/postgresrole"
+	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/database/postgressnapshot"
 	"github.com/crossplane-contrib/provider-in-cluster/pkg/controller/olm/operator"
 )
 
@@ -31,6 +36,11 @@ func Setup(mgr ctrl.Manager, l logging.Logger) error {
 	for _, setup := range []func(ctrl.Manager, logging.Logger) error{
 		config.Setup,
 		postgres.SetupPostgres,
+		postgresbackup.SetupPostgresBackup,
+		postgresdatabase.SetupPostgresDatabase,
+		postgresrole.SetupPostgresRole,
+		postgrespooler.SetupPostgresPooler,
+		postgressnapshot.SetupPostgresSnapshot,
 		operator.SetupOperator,
 	} {
 		if err := setup(mgr, l); err != nil {
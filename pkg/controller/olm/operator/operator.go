@@ -19,7 +19,6 @@ package operator
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -40,6 +39,8 @@ import (
 
 const (
 	errUnexpectedObject = "the managed resource is not a Postgres resource"
+	errListOperators    = "failed to list operators"
+	errNameConflict     = "operator %s conflicts with operator %s, both subscribe to %s in namespace %s"
 )
 
 // SetupOperator adds a controller that reconciles Operators.
@@ -80,11 +81,20 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	return &external{client: olmclient, logger: c.logger}, nil
+	kube, err := clients.NewKubeClient(rc)
+	if err != nil {
+		return nil, err
+	}
+	return &external{client: olmclient, kube: kube, local: c.kube, logger: c.logger}, nil
 }
 
+// external reconciles an Operator. kube is the client of the cluster the
+// operator is installed in, local the client of the cluster the managed
+// resource lives in.
 type external struct {
 	client operator.Client
+	kube   client.Client
+	local  client.Client
 	logger logging.Logger
 }
 
@@ -97,6 +107,14 @@ func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.E
 	// set initial default values
 	initializeDefaults(op)
 
+	// an operator that conflicts with an older one does not own the
+	// subscription. It does not exist, Create reports the conflict and a
+	// deleted operator is removed without touching the subscription.
+	other, err := e.olderConflict(ctx, op)
+	if err != nil || other != nil {
+		return managed.ExternalObservation{}, err
+	}
+
 	pm, err := e.client.GetPackageManifest(ctx, op)
 	if err != nil || pm == nil {
 		e.logger.Debug("Unable to find package manifest")
@@ -120,26 +138,74 @@ func (e *external) Observe(ctx context.Context, mgd resource.Managed) (managed.E
 
 	op.SetConditions(runtimev1alpha1.Available())
 
+	if utils.BoolValueFallback(op.Spec.ForProvider.CreateNamespace, false) {
+		updated, err = clients.IsNamespaceUpToDate(ctx, e.kube, op.Namespace, op.Spec.ForProvider.NamespaceLabels)
+		if err != nil {
+			return managed.ExternalObservation{ResourceExists: exists}, err
+		}
+	}
+
 	return managed.ExternalObservation{ResourceExists: exists, ResourceUpToDate: updated}, nil
 }
 
+// olderConflict returns an operator created before the given one that
+// subscribes to the same package in the same namespace of the same cluster
+func (e *external) olderConflict(ctx context.Context, op *v1alpha1.Operator) (*v1alpha1.Operator, error) {
+	l := &v1alpha1.OperatorList{}
+	if err := e.local.List(ctx, l); err != nil {
+		return nil, errors.Wrap(err, errListOperators)
+	}
+	for i := range l.Items {
+		other := &l.Items[i]
+		if clients.SameProviderConfig(op, other) && operator.Conflicts(op, other) && clients.CreatedBefore(other, op) {
+			return other, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkConflicts returns an error if an operator created before the given one
+// conflicts with it
+func (e *external) checkConflicts(ctx context.Context, op *v1alpha1.Operator) error {
+	other, err := e.olderConflict(ctx, op)
+	if err != nil || other == nil {
+		return err
+	}
+	return errors.Errorf(errNameConflict, op.Name, other.Name, op.Spec.ForProvider.OperatorName, operator.Namespace(op))
+}
+
+// applyNamespace creates the namespace of the operator with its namespace
+// labels, if the provider creates the namespace
+func (e *external) applyNamespace(ctx context.Context, op *v1alpha1.Operator) error {
+	if !utils.BoolValueFallback(op.Spec.ForProvider.CreateNamespace, false) {
+		return nil
+	}
+	return clients.EnsureNamespace(ctx, e.kube, op.Namespace, op.Spec.ForProvider.NamespaceLabels)
+}
+
 func (e *external) Create(ctx context.Context, mgd resource.Managed) (managed.ExternalCreation, error) {
 	op, ok := mgd.(*v1alpha1.Operator)
 	if !ok {
 		return managed.ExternalCreation{}, errors.New(errUnexpectedObject)
 	}
 
+	if err := e.checkConflicts(ctx, op); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	if err := e.applyNamespace(ctx, op); err != nil {
+		return managed.ExternalCreation{}, err
+	}
 	err := e.client.CreateOperator(ctx, op)
 
 	return managed.ExternalCreation{}, resource.Ignore(kerrors.IsAlreadyExists, err)
 }
 
 func (e *external) Update(ctx context.Context, mgd resource.Managed) (managed.ExternalUpdate, error) {
-	_, ok := mgd.(*v1alpha1.Operator)
+	op, ok := mgd.(*v1alpha1.Operator)
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errUnexpectedObject)
 	}
-	return managed.ExternalUpdate{}, nil
+	return managed.ExternalUpdate{}, e.applyNamespace(ctx, op)
 }
 
 func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
@@ -163,12 +229,7 @@ func (e *external) Delete(ctx context.Context, mgd resource.Managed) error {
 	return e.client.DeleteSubscription(ctx, op)
 }
 
-func initializeDefaults(op *v1alpha1.Operator) bool {
-	updated := false
-	// We need to set the default namespace here for the PV/PVC.
-	if strings.TrimSpace(op.Namespace) == "" {
-		op.Namespace = "default"
-		updated = true
-	}
-	return updated
+func initializeDefaults(op *v1alpha1.Operator) {
+	// the subscription of the operator is created in this namespace
+	op.Namespace = operator.Namespace(op)
 }
@@ -27,25 +27,36 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 // ExecIntoPod is used to select
-func ExecIntoPod(client kubernetes.Interface, dpl *appsv1.Deployment, cmd string) error {
+func ExecIntoPod(cfg *rest.Config, client kubernetes.Interface, dpl *appsv1.Deployment, cmd string) error {
 	command := []string{"/bin/bash", "-c", cmd}
 	pod, err := getDeploymentPod(client, dpl)
 	if err != nil {
 		return err
 	}
-	if _, stderr, err := exec(client, command, pod, dpl.Namespace); err != nil {
+	if _, stderr, err := exec(cfg, client, command, pod, "", dpl.Namespace); err != nil {
 		return errors.Wrapf(err, "failed to exec, %s", StringValueFallback(stderr, "no error message"))
 	}
 	return nil
 }
 
+// ExecInContainer runs the given shell command in a container of the given pod
+// and returns its output
+func ExecInContainer(cfg *rest.Config, client kubernetes.Interface, pod *corev1.Pod, container, cmd string) (string, error) {
+	command := []string{"/bin/bash", "-c", cmd}
+	stdout, stderr, err := exec(cfg, client, command, pod.Name, container, pod.Namespace)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to exec, %s", StringValueFallback(stderr, "no error message"))
+	}
+	return StringValue(stdout), nil
+}
+
 // run exec command on pod
-func exec(cs kubernetes.Interface, command []string, pod, ns string) (*string, *string, error) {
+func exec(cfg *rest.Config, cs kubernetes.Interface, command []string, pod, container, ns string) (*string, *string, error) {
 	req := cs.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(pod).
@@ -53,17 +64,14 @@ func exec(cs kubernetes.Interface, command []string, pod, ns string) (*string, *
 		SubResource("exec")
 
 	req.VersionedParams(&corev1.PodExecOptions{
-		Command: command,
-		Stdin:   false,
-		Stdout:  true,
-		Stderr:  true,
-		TTY:     false,
+		Container: container,
+		Command:   command,
+		Stdin:     false,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
 	}, scheme.ParameterCodec)
 
-	cfg, err := config.GetConfig()
-	if err != nil {
-		return nil, nil, err
-	}
 	exec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "error while creating executor")
@@ -139,7 +147,36 @@ func IntValue(i *int) int {
 	return *i
 }
 
+// IntValueFallback is a utility function converting an *int to an int with a fallback value
+func IntValueFallback(i *int, fb int) int {
+	if i == nil {
+		return fb
+	}
+	return *i
+}
+
 // Int32 is a utility function converting an int32 to a pointer
 func Int32(i int32) *int32 {
 	return &i
 }
+
+// Int32Value is a utility function converting an *int32 to a value
+func Int32Value(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// Bool is a utility function converting a bool to a pointer
+func Bool(b bool) *bool {
+	return &b
+}
+
+// BoolValueFallback is a utility function converting a *bool to a bool with a fallback value
+func BoolValueFallback(b *bool, fb bool) bool {
+	if b == nil {
+		return fb
+	}
+	return *b
+}